@@ -0,0 +1,159 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+// A message queued by the asynchronous mode, with its destinations, or a
+// request to be told through flushed when the previous ones are written.
+type queued struct {
+	e        record
+	toScreen bool
+	toSyslog bool
+	flushed  chan struct{}
+}
+
+// Enables the asynchronous mode with the given buffer size, see SetAsync.
+func (l *Logger) SetAsync(bufferSize int) {
+	l.stopAsync()
+	l.queueMu.Lock()
+	l.asyncSize = bufferSize
+	l.queueMu.Unlock()
+	l.startAsync()
+}
+
+// Sets the behavior of the asynchronous mode when its buffer is full, see
+// SetAsyncOverflow.
+func (l *Logger) SetAsyncOverflow(mode int) {
+	l.queueMu.Lock()
+	l.asyncOverflow = mode
+	l.queueMu.Unlock()
+}
+
+// Starts the goroutine writing the queued messages, if the asynchronous mode
+// is enabled and the goroutine is not running.
+func (l *Logger) startAsync() {
+	l.queueMu.Lock()
+	defer l.queueMu.Unlock()
+
+	if l.asyncSize <= 0 || l.queue != nil {
+		return
+	}
+
+	queue := make(chan queued, l.asyncSize)
+	done := make(chan struct{})
+	l.queue, l.queueDone = queue, done
+	go func() {
+		defer close(done)
+		for q := range queue {
+			if q.flushed != nil {
+				close(q.flushed)
+				continue
+			}
+			if err := l.deliver(q.e, q.toScreen, q.toSyslog); err != nil {
+				l.mu.Lock()
+				l.asyncErr = err
+				l.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stops the goroutine writing the queued messages, once they are all
+// written.
+func (l *Logger) stopAsync() {
+	l.queueMu.Lock()
+	queue, done := l.queue, l.queueDone
+	l.queue, l.queueDone = nil, nil
+	if queue != nil {
+		close(queue)
+	}
+	l.queueMu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// Waits for the messages queued so far to be written, if the asynchronous
+// mode is running.
+func (l *Logger) drain() {
+	l.queueMu.RLock()
+	if l.queue == nil {
+		l.queueMu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	l.queue <- queued{flushed: flushed}
+	l.queueMu.RUnlock()
+	<-flushed
+}
+
+// Queues a message if the asynchronous mode is running.
+// Returns false if the message must be written synchronously.
+func (l *Logger) enqueue(e record, toScreen, toSyslog bool) bool {
+	l.queueMu.RLock()
+	defer l.queueMu.RUnlock()
+
+	if l.queue == nil {
+		return false
+	}
+
+	// The fields may be changed by the caller once logged.
+	e.fields = e.fields.clone()
+	q := queued{e: e, toScreen: toScreen, toSyslog: toSyslog}
+	if l.asyncOverflow != ASYNC_DROP {
+		l.queue <- q
+		return true
+	}
+
+	for {
+		select {
+		case l.queue <- q:
+			return true
+		default:
+		}
+
+		select {
+		case old := <-l.queue:
+			if old.flushed != nil {
+				// Never drop the request of a waiting Flush: queue it
+				// again, then wait for room as the writer catches up.
+				l.queue <- old
+				l.queue <- q
+				return true
+			}
+			l.mu.Lock()
+			l.suppressed++
+			l.mu.Unlock()
+		default:
+		}
+	}
+}
+
+// Waits for the queued messages to be written, see Flush.
+func (l *Logger) Flush() error {
+	l.drain()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.asyncErr
+	l.asyncErr = nil
+	return err
+}
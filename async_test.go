@@ -0,0 +1,129 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Writer sleeping before every write, to keep messages in the queue.
+type slowWriter struct {
+	mu    sync.Mutex
+	delay time.Duration
+	buf   bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestFlushNotDropped(t *testing.T) {
+	w := &slowWriter{delay: 50 * time.Millisecond}
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(w)
+	l.SetAsync(1)
+	l.SetAsyncOverflow(ASYNC_DROP)
+	defer l.stopAsync()
+
+	l.Err("a")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Flush()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.Err("b")
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush did not return")
+	}
+}
+
+func TestFlushWritesQueued(t *testing.T) {
+	w := &slowWriter{delay: time.Millisecond}
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(w)
+	l.SetAsync(16)
+	defer l.stopAsync()
+
+	for i := 0; i < 5; i++ {
+		l.Notice("queued")
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count([]byte(w.String()), []byte("queued")); n != 5 {
+		t.Fatalf("got %d messages after Flush, want 5", n)
+	}
+}
+
+func TestSyncOption(t *testing.T) {
+	w := &slowWriter{delay: time.Millisecond}
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(w)
+	l.SetAsync(16)
+	defer l.stopAsync()
+
+	l.Notice("queued")
+	l.LogOpt(LogOptions{Level: L_NOTICE, Message: "sync", Sync: true})
+	out := w.String()
+	if !strings.Contains(out, "queued") || !strings.Contains(out, "sync") || strings.Index(out, "queued") > strings.Index(out, "sync") {
+		t.Fatalf("got %q, want both messages in order", out)
+	}
+}
+
+func TestPanicNotDropped(t *testing.T) {
+	w := &slowWriter{delay: time.Millisecond}
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(w)
+	l.SetAsync(16)
+	l.SetRateLimit(1)
+	l.SetDedup(true)
+	defer l.stopAsync()
+
+	l.Crit("boom")
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+	if n := strings.Count(w.String(), "boom"); n != 2 {
+		t.Fatalf("got %d messages before the panic, want 2", n)
+	}
+}
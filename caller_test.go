@@ -0,0 +1,220 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/arclabch/logger"
+)
+
+// Returns a logger capturing messages with their caller.
+func newCallerLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	l, err := logger.NewFile(filepath.Join(t.TempDir(), "test.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	l.SetDryRun(true)
+	l.SetCaller(true)
+	l.SetVerbose(true)
+	return l
+}
+
+// Calls each logging function, written on a single line, and checks that
+// the message logged reports that line.
+func checkCallers(t *testing.T, output func() []string, calls []func()) {
+	t.Helper()
+	for _, call := range calls {
+		f := runtime.FuncForPC(reflect.ValueOf(call).Pointer())
+		_, line := f.FileLine(f.Entry())
+		call()
+		checkCaller(t, output, line)
+	}
+}
+
+// Returns the line following the caller.
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+// Checks that the last message captured in dry run mode was logged from
+// line.
+func checkCaller(t *testing.T, output func() []string, line int) {
+	t.Helper()
+	out := output()
+	if len(out) == 0 {
+		t.Fatal("nothing logged")
+	}
+	want := fmt.Sprintf("caller_test.go:%d ", line)
+	if got := out[len(out)-1]; !strings.Contains(got, want) {
+		t.Errorf("got %q, want caller %q", got, want)
+	}
+}
+
+func TestCallerStdLogger(t *testing.T) {
+	l := newCallerLogger(t)
+
+	line := nextLine()
+	l.StdLogger(logger.L_ERROR).Print("message")
+	checkCaller(t, l.DryRunOutput, line)
+
+	line = nextLine()
+	l.StdLogger(logger.L_ERROR).Printf("message %d", 1)
+	checkCaller(t, l.DryRunOutput, line)
+
+	line = nextLine()
+	log.New(l.Writer(logger.L_ERROR), "", log.Lshortfile).Println("message")
+	checkCaller(t, l.DryRunOutput, line)
+}
+
+func TestCallerSlog(t *testing.T) {
+	l := newCallerLogger(t)
+	s := slog.New(logger.NewSlogHandler(logger.WithLogger(l)))
+
+	line := nextLine()
+	s.Error("message")
+	checkCaller(t, l.DryRunOutput, line)
+
+	line = nextLine()
+	s.With("k", "v").Warn("message")
+	checkCaller(t, l.DryRunOutput, line)
+}
+
+func TestCallerPackageFunctions(t *testing.T) {
+	logger.SetDryRun(true)
+	logger.SetCaller(true)
+	logger.SetVerbose(true)
+	defer func() {
+		logger.SetDryRun(false)
+		logger.SetCaller(false)
+		logger.SetVerbose(false)
+	}()
+
+	ctx := context.Background()
+	err := errors.New("failure")
+	checkCallers(t, logger.DryRunOutput, []func(){
+		func() { logger.Emerg("message") },
+		func() { logger.Alert("message") },
+		func() { logger.Crit("message") },
+		func() { logger.Err("message") },
+		func() { logger.Warning("message") },
+		func() { logger.Notice("message") },
+		func() { logger.Info("message") },
+		func() { logger.Debug("message") },
+		func() { logger.Emergencyf("message %d", 1) },
+		func() { logger.Alertf("message %d", 1) },
+		func() { logger.Criticalf("message %d", 1) },
+		func() { logger.Errorf("message %d", 1) },
+		func() { logger.Warningf("message %d", 1) },
+		func() { logger.Noticef("message %d", 1) },
+		func() { logger.Infof("message %d", 1) },
+		func() { logger.Debugf("message %d", 1) },
+		func() { logger.ErrorContext(ctx, "message") },
+		func() { logger.ErrorErr(err, "message") },
+		func() { logger.LogKeyed("key", logger.L_ERROR, "message") },
+		func() { logger.WithFields(logger.Fields{"k": "v"}).Err("message") },
+	})
+}
+
+func TestCallerLoggerMethods(t *testing.T) {
+	l := newCallerLogger(t)
+	e := l.WithFields(logger.Fields{"k": "v"})
+	ctx := context.Background()
+	err := errors.New("failure")
+	checkCallers(t, l.DryRunOutput, []func(){
+		func() { l.Emerg("message") },
+		func() { l.Alert("message") },
+		func() { l.Crit("message") },
+		func() { l.Err("message") },
+		func() { l.Warning("message") },
+		func() { l.Notice("message") },
+		func() { l.Info("message") },
+		func() { l.Debug("message") },
+		func() { l.Emergencyf("message %d", 1) },
+		func() { l.Alertf("message %d", 1) },
+		func() { l.Criticalf("message %d", 1) },
+		func() { l.Errorf("message %d", 1) },
+		func() { l.Warningf("message %d", 1) },
+		func() { l.Noticef("message %d", 1) },
+		func() { l.Infof("message %d", 1) },
+		func() { l.Debugf("message %d", 1) },
+		func() { l.ErrorContext(ctx, "message") },
+		func() { l.ErrorErr(err, "message") },
+		func() { l.LogOpt(logger.LogOptions{Level: logger.L_ERROR, Message: "message"}) },
+		func() { l.LogChange("value", 1, 2) },
+		func() { e.Err("message") },
+		func() { e.Errorf("message %d", 1) },
+		func() { e.WithPrefix("prefix").Warning("message") },
+		func() { l.Named("component").Err("message") },
+	})
+}
+
+// Wrapper of the logging functions, reporting its caller with
+// SetCallerSkip.
+func logWrapped(l *logger.Logger, message string) {
+	l.Err(message)
+}
+
+func TestCallerSkip(t *testing.T) {
+	l := newCallerLogger(t)
+	l.SetCallerSkip(1)
+
+	line := nextLine()
+	logWrapped(l, "message")
+	checkCaller(t, l.DryRunOutput, line)
+}
+
+func TestCallerStyle(t *testing.T) {
+	l := newCallerLogger(t)
+
+	for _, c := range []struct {
+		style int
+		want  string
+	}{
+		{logger.CALLER_BOTH, ": caller_test.go:%d logger_test.TestCallerStyle message"},
+		{logger.CALLER_FILE, ": caller_test.go:%d message"},
+		{logger.CALLER_FUNC, ": logger_test.TestCallerStyle message"},
+	} {
+		l.SetCallerStyle(c.style)
+		line := nextLine()
+		l.Err("message")
+		out := l.DryRunOutput()
+		want := c.want
+		if strings.Contains(want, "%d") {
+			want = fmt.Sprintf(want, line)
+		}
+		if got := out[len(out)-1]; !strings.HasSuffix(got, want) {
+			t.Errorf("style %d: got %q, want suffix %q", c.style, got, want)
+		}
+	}
+}
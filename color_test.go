@@ -0,0 +1,117 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestApplyColorStyle(t *testing.T) {
+	for _, tt := range []struct {
+		style int
+		in    string
+		want  string
+	}{
+		{COLOR_FG, C_RED, "\x1b[31m"},
+		{COLOR_BG, C_RED, "\x1b[41m"},
+		{COLOR_BOTH, C_RED, C_RED},
+		{COLOR_FG, Color256(45), "\x1b[38;5;45m"},
+		{COLOR_BG, Color256(45), "\x1b[38;5;45m"},
+		{COLOR_FG, "\x1b[38;2;45;40;49m", "\x1b[38;2;45;40;49m"},
+		{COLOR_FG, "\x1b[38;5;45;44m", "\x1b[34m"},
+	} {
+		if got := (lineFormat{colorStyle: tt.style}).applyColorStyle(tt.in); got != tt.want {
+			t.Errorf("style %d, %q: got %q, want %q", tt.style, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestColorRGB(t *testing.T) {
+	t.Setenv("COLORTERM", "")
+	if got := ColorRGB(255, 0, 0); got != Color256(196) {
+		t.Errorf("got %q, want the 256-color red", got)
+	}
+	t.Setenv("COLORTERM", "truecolor")
+	if got := ColorRGB(1, 2, 3); got != "\x1b[38;2;1;2;3m" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLogChangeColored(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "1")
+	var buf bytes.Buffer
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(&buf)
+	l.EnableColor()
+	l.SetColorStyle(COLOR_FG)
+	l.SetCaller(true)
+
+	l.LogChange("workers", 2, 4)
+	got := buf.String()
+	if !regexp.MustCompile(`\.go:\d+ \S+ workers: `).MatchString(got) {
+		t.Errorf("caller missing: %q", got)
+	}
+	if !strings.Contains(got, "workers: \x1b[31m2\x1b[0m → \x1b[32m4\x1b[0m") {
+		t.Errorf("colors do not follow the style: %q", got)
+	}
+}
+
+func TestColorFollowsOutput(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "0")
+	l := newLogger()
+	l.SetScreenOutput(&bytes.Buffer{})
+	if l.colored() {
+		t.Fatal("colors enabled on a buffer")
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	l.SetScreenOutput(&bytes.Buffer{})
+	if !l.colored() {
+		t.Fatal("colors not enabled again on a color-capable output")
+	}
+
+	l.DisableColor()
+	l.SetScreenOutput(&bytes.Buffer{})
+	if l.colored() {
+		t.Fatal("colors enabled again after DisableColor")
+	}
+}
+
+func TestApplyNoColor(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "1")
+	l := newLogger()
+	l.DisableColor()
+	c := l.Snapshot()
+	if !c.NoColor {
+		t.Fatal("DisableColor not captured by Snapshot")
+	}
+
+	m := newLogger()
+	m.Apply(c)
+	m.SetScreenOutput(&bytes.Buffer{})
+	if m.colored() {
+		t.Fatal("colors enabled again after applying a configuration without colors")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import "strings"
+
+// Returns an entry logging as a component, see Named.
+func (l *Logger) Named(name string) *Entry {
+	return (&Entry{logger: l}).Named(name)
+}
+
+// Returns a new entry logging as a child of the component of the entry, the
+// names being joined with a dot.
+func (e *Entry) Named(name string) *Entry {
+	if e.component != "" {
+		name = e.component + "." + name
+	}
+	return &Entry{Fields: e.Fields, prefix: e.prefix, component: name, logger: e.logger}
+}
+
+// Returns the tag of the messages of a component, the tag of the logger
+// followed by the component name.
+func componentTag(tag, component string) string {
+	if component == "" {
+		return tag
+	}
+	return tag + "." + component
+}
+
+// Sets the least severe level logged by a component, see
+// SetComponentLevel.
+func (l *Logger) SetComponentLevel(name string, level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level < 0 {
+		delete(l.components, name)
+		return
+	}
+	if l.components == nil {
+		l.components = make(map[string]int)
+	}
+	l.components[name] = level
+}
+
+// Returns the level set for a component or for the closest of its parents.
+// Returns false if none is set.
+func (l *Logger) componentLevel(name string) (int, bool) {
+	if name == "" {
+		return 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for {
+		if level, ok := l.components[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Key of the entry stored in a context by NewContext.
+type entryKey struct{}
+
+// Returns a copy of ctx carrying an entry, whose fields are then logged by
+// the Context functions and the slog handler, and which handlers deeper in
+// the call stack can get back with FromContext.
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
+
+// Returns the entry stored in ctx by NewContext, or an entry of the default
+// logger without fields if there is none.
+func FromContext(ctx context.Context) *Entry {
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok && e != nil {
+		return e
+	}
+	return &Entry{logger: std}
+}
+
+// Key of the correlation ID stored in a context by WithCorrelationID.
+type corrIDKey struct{}
+
+// Returns a new random correlation ID of 16 hexadecimal characters, such as
+// to store one per request with WithCorrelationID.
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Returns a copy of ctx carrying a correlation ID, logged as the "corr_id"
+// field by the Context functions and the slog handler.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, corrIDKey{}, id)
+}
+
+// Returns the correlation ID stored in ctx by WithCorrelationID, or an empty
+// string if there is none, such as to pass it on to another service.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(corrIDKey{}).(string)
+	return id
+}
+
+// Logs a correlation ID for the process, see SetProcessCorrelationID.
+func (l *Logger) SetProcessCorrelationID(b bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.corrOn = b
+	if !b {
+		l.corrID = ""
+	} else if l.corrID == "" && (l.s != nil || l.file != nil) {
+		l.corrID = NewCorrelationID()
+	}
+}
+
+// Returns a copy of fields with a correlation ID added, unless already set.
+func withCorrelationID(fields Fields, id string) Fields {
+	if _, ok := fields["corr_id"]; ok {
+		return fields
+	}
+	f := make(Fields, len(fields)+1)
+	for k, v := range fields {
+		f[k] = v
+	}
+	f["corr_id"] = id
+	return f
+}
+
+// A context value logged as a field, see RegisterContextField.
+type contextField struct {
+	key  interface{}
+	name string
+}
+
+// Registers a context value to log as a field, see RegisterContextField.
+func (l *Logger) RegisterContextField(key interface{}, fieldName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, f := range l.ctxFields {
+		if f.key == key {
+			l.ctxFields[i].name = fieldName
+			return
+		}
+	}
+	l.ctxFields = append(l.ctxFields, contextField{key, fieldName})
+}
+
+// Returns the fields of the entry stored in a context and the registered
+// values found in it, as fields.
+func (l *Logger) fieldsFromContext(ctx context.Context) Fields {
+	l.mu.Lock()
+	registered := l.ctxFields
+	l.mu.Unlock()
+
+	fields := make(Fields, len(registered))
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok && e != nil {
+		for k, v := range e.Fields {
+			fields[k] = v
+		}
+	}
+	if id := CorrelationID(ctx); id != "" {
+		fields["corr_id"] = id
+	}
+	for _, f := range registered {
+		if v := ctx.Value(f.key); v != nil {
+			fields[f.name] = v
+		}
+	}
+	return fields
+}
+
+// Returns an entry logging the fields of a context, see WithContext.
+func (l *Logger) WithContext(ctx context.Context) *Entry {
+	return l.WithFields(l.fieldsFromContext(ctx))
+}
+
+// Logs an Emergency-level event with the values of a context, see
+// EmergencyContext.
+func (l *Logger) EmergencyContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Emerg(message)
+}
+
+// Logs an Alert-level event with the values of a context, see AlertContext.
+func (l *Logger) AlertContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Alert(message)
+}
+
+// Logs a Critical-level event with the values of a context, see
+// CriticalContext.
+func (l *Logger) CriticalContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Crit(message)
+}
+
+// Logs an Error-level event with the values of a context, see ErrorContext.
+func (l *Logger) ErrorContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Err(message)
+}
+
+// Logs a Warning-level event with the values of a context, see
+// WarningContext.
+func (l *Logger) WarningContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Warning(message)
+}
+
+// Logs a Notice-level event with the values of a context, see
+// NoticeContext.
+func (l *Logger) NoticeContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Notice(message)
+}
+
+// Logs an Info-level event with the values of a context, see InfoContext.
+func (l *Logger) InfoContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Info(message)
+}
+
+// Logs a Debug-level event with the values of a context, see DebugContext.
+func (l *Logger) DebugContext(ctx context.Context, message string) error {
+	return l.WithContext(ctx).Debug(message)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestCorrelationIDs(t *testing.T) {
+	l := newLogger()
+	l.SetProcessCorrelationID(true)
+	if err := l.openFile(filepath.Join(t.TempDir(), "test.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+
+	id := NewCorrelationID()
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(id) {
+		t.Fatalf("got correlation ID %q", id)
+	}
+	ctx := WithCorrelationID(context.Background(), id)
+	if got := CorrelationID(ctx); got != id {
+		t.Fatalf("got %q from the context, want %q", got, id)
+	}
+
+	l.Err("first")
+	l.Err("second")
+	l.ErrorContext(ctx, "request")
+
+	out := l.DryRunOutput()
+	process := regexp.MustCompile(`"corr_id":"([0-9a-f]{16})"`).FindStringSubmatch(out[0])
+	if process == nil {
+		t.Fatalf("no process correlation ID in %s", out[0])
+	}
+	if want := `{"level":"ERROR","msg":"second","corr_id":"` + process[1] + `"}`; out[1] != want {
+		t.Errorf("got %s, want %s", out[1], want)
+	}
+	if want := `{"level":"ERROR","msg":"request","corr_id":"` + id + `"}`; out[2] != want {
+		t.Errorf("got %s, want %s", out[2], want)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+// Metadata of the CloudWatch embedded metric format, as the "_aws" member of
+// a JSON object.
+type emfMetadata struct {
+	Timestamp         int64
+	CloudWatchMetrics []emfDirective
+}
+
+// Metrics extracted by CloudWatch from a message.
+type emfDirective struct {
+	Namespace  string
+	Dimensions [][]string
+	Metrics    []emfMetric
+}
+
+// A metric, named after its field.
+type emfMetric struct {
+	Name string
+}
+
+// Sets the namespace of the metrics, see SetEMFNamespace.
+func (l *Logger) SetEMFNamespace(ns string) {
+	l.mu.Lock()
+	l.emfNS = ns
+	l.mu.Unlock()
+}
+
+// Sets the fields logged as metrics, see SetEMFMetrics.
+func (l *Logger) SetEMFMetrics(keys ...string) {
+	var metrics map[string]bool
+	if len(keys) > 0 {
+		metrics = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			metrics[k] = true
+		}
+	}
+
+	l.mu.Lock()
+	l.emfMetrics = metrics
+	l.mu.Unlock()
+}
+
+// Sets the fields logged as dimensions, see SetEMFDimensions.
+func (l *Logger) SetEMFDimensions(keys ...string) {
+	l.mu.Lock()
+	l.emfDims = append([]string(nil), keys...)
+	l.mu.Unlock()
+}
+
+// Returns the CloudWatch metadata of a record in the EMF format, or nil if
+// not in that format or if the record has no metric.
+func (l lineFormat) emfMetadata(e record) *emfMetadata {
+	if l.format != FORMAT_EMF {
+		return nil
+	}
+
+	dims := []string{}
+	isDim := make(map[string]bool, len(l.emfDims))
+	for _, k := range l.emfDims {
+		isDim[k] = true
+		if _, ok := e.fields[k]; ok {
+			dims = append(dims, k)
+		}
+	}
+
+	var metrics []emfMetric
+	for _, k := range e.fields.keys() {
+		if isDim[k] || (l.emfMetrics != nil && !l.emfMetrics[k]) || !isNumber(e.fields[k]) {
+			continue
+		}
+		metrics = append(metrics, emfMetric{k})
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	ns := l.emfNS
+	if ns == "" {
+		ns = l.tag
+	}
+	if ns == "" {
+		ns = "aws-embedded-metrics"
+	}
+	t := e.time
+	if t.IsZero() {
+		t = l.now()
+	}
+	return &emfMetadata{
+		Timestamp: t.UnixNano() / 1e6,
+		CloudWatchMetrics: []emfDirective{{
+			Namespace:  ns,
+			Dimensions: [][]string{dims},
+			Metrics:    metrics,
+		}},
+	}
+}
+
+// Tells if a field value is a number.
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
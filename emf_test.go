@@ -0,0 +1,56 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEMF(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_EMF)
+	l.SetTimeFormat(TIME_NONE)
+	l.SetTimeSource(func() time.Time { return time.Unix(1767323045, 123e6) })
+	l.SetEMFNamespace("shop")
+	l.SetEMFDimensions("service", "region")
+
+	l.WithFields(Fields{"service": "cart", "latency": 12.5, "items": 3, "user": "bob"}).Err("checkout")
+	l.WithFields(Fields{"user": "bob"}).Err("no metric")
+	l.SetEMFMetrics("latency")
+	l.WithFields(Fields{"latency": 1, "items": 3, "_aws": "spoofed"}).Err("only latency")
+
+	out := l.DryRunOutput()
+	for i, want := range []string{
+		`{"_aws":{"Timestamp":1767323045123,"CloudWatchMetrics":[{"Namespace":"shop","Dimensions":[["service"]],` +
+			`"Metrics":[{"Name":"items"},{"Name":"latency"}]}]},` +
+			`"level":"ERROR","msg":"checkout","items":3,"latency":12.5,"service":"cart","user":"bob"}`,
+		`{"level":"ERROR","msg":"no metric","user":"bob"}`,
+		`{"_aws":{"Timestamp":1767323045123,"CloudWatchMetrics":[{"Namespace":"shop","Dimensions":[[]],` +
+			`"Metrics":[{"Name":"latency"}]}]},` +
+			`"level":"ERROR","msg":"only latency","fields._aws":"spoofed","items":3,"latency":1}`,
+	} {
+		if out[i] != want {
+			t.Errorf("line %d = %s, want %s", i, out[i], want)
+		}
+	}
+}
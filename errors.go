@@ -0,0 +1,113 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// Returns the fields describing an error: its message as "error", the
+// messages of the errors it wraps as "causes", and the stack as "stack" if
+// enabled with SetErrorStack.
+func (l *Logger) errorFields(err error) Fields {
+	fields := Fields{}
+	if err != nil {
+		fields["error"] = err.Error()
+
+		var causes []string
+		for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+			causes = append(causes, cause.Error())
+		}
+		if len(causes) > 0 {
+			fields["causes"] = causes
+		}
+	}
+	l.mu.Lock()
+	stack := l.errorStack
+	l.mu.Unlock()
+	if stack {
+		fields["stack"] = string(debug.Stack())
+	}
+	return fields
+}
+
+// Returns an entry logging an error as structured fields, see WithError.
+func (l *Logger) WithError(err error) *Entry {
+	return (&Entry{logger: l}).WithError(err)
+}
+
+// Returns a new entry with an error added to the fields of the entry: its
+// message as "error" and its type as "errorType". A nil error adds no field.
+func (e *Entry) WithError(err error) *Entry {
+	if err == nil {
+		return e.WithFields(nil)
+	}
+	return e.WithFields(Fields{"error": err.Error(), "errorType": fmt.Sprintf("%T", err)})
+}
+
+// Records the stack in the fields of the Err functions, see SetErrorStack.
+func (l *Logger) SetErrorStack(b bool) {
+	l.mu.Lock()
+	l.errorStack = b
+	l.mu.Unlock()
+}
+
+// Logs an Emergency-level event with an error, see EmergencyErr.
+func (l *Logger) EmergencyErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Emerg(message)
+}
+
+// Logs an Alert-level event with an error, see AlertErr.
+func (l *Logger) AlertErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Alert(message)
+}
+
+// Logs a Critical-level event with an error, see CriticalErr.
+func (l *Logger) CriticalErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Crit(message)
+}
+
+// Logs an Error-level event with an error, see ErrorErr.
+func (l *Logger) ErrorErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Err(message)
+}
+
+// Logs a Warning-level event with an error, see WarningErr.
+func (l *Logger) WarningErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Warning(message)
+}
+
+// Logs a Notice-level event with an error, see NoticeErr.
+func (l *Logger) NoticeErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Notice(message)
+}
+
+// Logs an Info-level event with an error, see InfoErr.
+func (l *Logger) InfoErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Info(message)
+}
+
+// Logs a Debug-level event with an error, see DebugErr.
+func (l *Logger) DebugErr(err error, message string) error {
+	return l.WithFields(l.errorFields(err)).Debug(message)
+}
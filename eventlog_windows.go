@@ -0,0 +1,67 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build windows
+
+package logger
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Unused on Windows, where messages are sent to the Event Log.
+type Priority int
+
+// Unused on Windows.
+const defaultPriority Priority = 0
+
+// Event ID of the messages sent to the Event Log.
+const eventID = 1
+
+// Writes messages to the Windows Event Log, mapping levels to event types.
+type eventLogWriter struct {
+	l *eventlog.Log
+}
+
+// Opens the Event Log with the tag as source. The source must have been
+// registered, such as with eventlog.InstallAsEventCreate. Remote servers are
+// not supported.
+func dialSyslog(network, raddr string, priority Priority, tag string) (syslogWriter, error) {
+	if network != "" || raddr != "" {
+		return nil, errors.New("logger: remote Syslog is not supported on Windows")
+	}
+	l, err := eventlog.Open(tag)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogWriter{l: l}, nil
+}
+
+func (w *eventLogWriter) Emerg(m string) error   { return w.l.Error(eventID, m) }
+func (w *eventLogWriter) Alert(m string) error   { return w.l.Error(eventID, m) }
+func (w *eventLogWriter) Crit(m string) error    { return w.l.Error(eventID, m) }
+func (w *eventLogWriter) Err(m string) error     { return w.l.Error(eventID, m) }
+func (w *eventLogWriter) Warning(m string) error { return w.l.Warning(eventID, m) }
+func (w *eventLogWriter) Notice(m string) error  { return w.l.Info(eventID, m) }
+func (w *eventLogWriter) Info(m string) error    { return w.l.Info(eventID, m) }
+func (w *eventLogWriter) Debug(m string) error   { return w.l.Info(eventID, m) }
+func (w *eventLogWriter) Close() error           { return w.l.Close() }
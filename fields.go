@@ -0,0 +1,207 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Structured key/value fields attached to a message.
+type Fields map[string]interface{}
+
+// A message being built with structured fields, a prefix or a component, as
+// returned by WithFields, WithPrefix and Named. The component and the prefix
+// are prepended to the message. On screen, fields are appended to the
+// message as key=value pairs, or added to the object in the JSON format.
+// Messages sent to Syslog carry them as an RFC 5424 structured-data element.
+type Entry struct {
+	Fields    Fields
+	prefix    string
+	component string
+	logger    *Logger
+}
+
+// Returns the keys of the fields, sorted.
+func (f Fields) keys() []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns a copy of the fields, or nil if there are none.
+func (f Fields) clone() Fields {
+	if len(f) == 0 {
+		return nil
+	}
+	c := make(Fields, len(f))
+	for k, v := range f {
+		c[k] = v
+	}
+	return c
+}
+
+// Returns an entry logging with the given fields.
+func (l *Logger) WithFields(fields Fields) *Entry {
+	return (&Entry{logger: l}).WithFields(fields)
+}
+
+// Returns a new entry with the given fields merged into the fields of the
+// entry. Fields with the same key are replaced.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.Fields)+len(fields))
+	for k, v := range e.Fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{Fields: merged, prefix: e.prefix, component: e.component, logger: e.logger}
+}
+
+// Returns an entry prepending the given prefix to messages.
+func (l *Logger) WithPrefix(prefix string) *Entry {
+	return (&Entry{logger: l}).WithPrefix(prefix)
+}
+
+// Returns a new entry with the given prefix appended to the prefix of the
+// entry, separated by a space.
+func (e *Entry) WithPrefix(prefix string) *Entry {
+	if e.prefix != "" {
+		prefix = e.prefix + " " + prefix
+	}
+	return &Entry{Fields: e.Fields, prefix: prefix, component: e.component, logger: e.logger}
+}
+
+// Logs a message with the prefix and fields of the entry.
+func (e *Entry) write(level int, message string) error {
+	if e.prefix != "" {
+		message = e.prefix + " " + message
+	}
+	return e.logger.write(record{level: level, message: message, fields: e.Fields, component: e.component})
+}
+
+// Logs an Emergency-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Emerg(message string) error {
+	return e.write(L_EMERGENCY, message)
+}
+
+// Logs an Alert-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Alert(message string) error {
+	return e.write(L_ALERT, message)
+}
+
+// Logs a Critical-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Crit(message string) error {
+	return e.write(L_CRITICAL, message)
+}
+
+// Logs an Error-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Err(message string) error {
+	return e.write(L_ERROR, message)
+}
+
+// Logs a Warning-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Warning(message string) error {
+	return e.write(L_WARNING, message)
+}
+
+// Logs a Notice-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Notice(message string) error {
+	return e.write(L_NOTICE, message)
+}
+
+// Logs an Info-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Info(message string) error {
+	return e.write(L_INFO, message)
+}
+
+// Logs a Debug-level event with the fields of the entry.
+// Returns an error if unable to log it.
+func (e *Entry) Debug(message string) error {
+	return e.write(L_DEBUG, message)
+}
+
+// Logs an Emergency-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Emergencyf(format string, a ...interface{}) error {
+	return e.Emerg(fmt.Sprintf(format, a...))
+}
+
+// Logs an Alert-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Alertf(format string, a ...interface{}) error {
+	return e.Alert(fmt.Sprintf(format, a...))
+}
+
+// Logs a Critical-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Criticalf(format string, a ...interface{}) error {
+	return e.Crit(fmt.Sprintf(format, a...))
+}
+
+// Logs an Error-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Errorf(format string, a ...interface{}) error {
+	return e.Err(fmt.Sprintf(format, a...))
+}
+
+// Logs a Warning-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Warningf(format string, a ...interface{}) error {
+	return e.Warning(fmt.Sprintf(format, a...))
+}
+
+// Logs a Notice-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Noticef(format string, a ...interface{}) error {
+	return e.Notice(fmt.Sprintf(format, a...))
+}
+
+// Logs an Info-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Infof(format string, a ...interface{}) error {
+	return e.Info(fmt.Sprintf(format, a...))
+}
+
+// Logs a Debug-level event with the fields of the entry, formatted
+// according to a format specifier.
+// Returns an error if unable to log it.
+func (e *Entry) Debugf(format string, a ...interface{}) error {
+	return e.Debug(fmt.Sprintf(format, a...))
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Rotation settings of a log file, see OpenFileWithOptions.
+type FileOptions struct {
+	MaxSizeMB  int  // Size beyond which the file is rotated, never if 0
+	MaxAgeDays int  // Age beyond which backups are removed, never if 0
+	MaxBackups int  // Backups kept, FILE_BACKUPS if 0
+	Compress   bool // Backups compressed with gzip
+}
+
+// A log file rotated when it grows beyond a maximum size.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	backups  int
+	maxAge   time.Duration
+	compress bool
+	f        *os.File
+	size     int64
+}
+
+// Opens a log file for appending, creating it if needed.
+func openRotatingFile(path string, maxBytes int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.reopen(os.O_APPEND); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Opens a log file for appending with the given rotation settings.
+func openRotatingFileWithOptions(path string, opts FileOptions) (*rotatingFile, error) {
+	backups := opts.MaxBackups
+	if backups == 0 {
+		backups = FILE_BACKUPS
+	}
+
+	r, err := openRotatingFile(path, int64(opts.MaxSizeMB)<<20, backups)
+	if err != nil {
+		return nil, err
+	}
+	r.maxAge = time.Duration(opts.MaxAgeDays) * 24 * time.Hour
+	r.compress = opts.Compress
+	return r, nil
+}
+
+// Writes p to the file, rotating it first if p would make it grow beyond
+// its maximum size. If the rotation fails, p is still written to the current
+// file and the error is returned.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	var rotateErr error
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		rotateErr = r.rotate()
+		if r.f == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, joinErrors(rotateErr, err)
+}
+
+// Closes the file.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// Shifts the backups, renames the current file to the first backup and
+// starts a new file. If that fails, the current file is opened again so that
+// logging goes on, and the rotation is tried again on the next write. Must
+// be called with the lock held.
+func (r *rotatingFile) rotate() error {
+	err := r.f.Close()
+	r.f = nil
+	if err == nil {
+		err = r.shiftBackups()
+	}
+	if err != nil {
+		return joinErrors(err, r.reopen(os.O_APPEND))
+	}
+
+	return r.reopen(os.O_TRUNC)
+}
+
+// Shifts the backups and moves the current file to the first one.
+func (r *rotatingFile) shiftBackups() error {
+	if r.backups <= 0 {
+		return nil
+	}
+
+	for i := r.backups - 1; i > 0; i-- {
+		err := os.Rename(r.backupPath(i), r.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if r.compress {
+		if err := compressFile(r.path, r.backupPath(1)); err != nil {
+			return err
+		}
+	} else if err := os.Rename(r.path, r.backupPath(1)); err != nil {
+		return err
+	}
+	r.removeExpired()
+	return nil
+}
+
+// Removes the backups older than the maximum age, if any.
+func (r *rotatingFile) removeExpired() {
+	if r.maxAge <= 0 {
+		return
+	}
+
+	for i := 1; i <= r.backups; i++ {
+		info, err := os.Stat(r.backupPath(i))
+		if err == nil && time.Since(info.ModTime()) > r.maxAge {
+			os.Remove(r.backupPath(i))
+		}
+	}
+}
+
+// Returns the path of the nth backup, ending with .gz if compressed.
+func (r *rotatingFile) backupPath(n int) string {
+	if r.compress {
+		return fmt.Sprintf("%s.%d.gz", r.path, n)
+	}
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+// Compresses a file with gzip into dst and removes it.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err2 := out.Close(); err == nil {
+		err = err2
+	}
+	in.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// Opens the file with the given extra flag and records its size.
+func (r *rotatingFile) reopen(flag int) error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
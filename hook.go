@@ -0,0 +1,171 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import "sync"
+
+// A function called for the messages logged, such as to forward some of
+// them to an alerting service. Fire receives a copy of the fields of the
+// message, which it may change to add, redact or remove fields before the
+// message is written.
+type Hook interface {
+	Fire(level int, message string, fields Fields) error
+}
+
+// A hook with the levels it is called for, as a bit mask.
+type levelHook struct {
+	hook   Hook
+	levels uint8
+}
+
+// Adds a hook called for the messages of the given levels, see AddHook.
+func (l *Logger) AddHook(h Hook, levels ...int) {
+	mask := uint8(0xff)
+	if len(levels) > 0 {
+		mask = 0
+		for _, level := range levels {
+			if IsValidLevel(level) {
+				mask |= 1 << level
+			}
+		}
+	}
+
+	l.mu.Lock()
+	l.hooks = append(l.hooks, levelHook{h, mask})
+	l.mu.Unlock()
+}
+
+// Calls the hooks registered for the level of a record, letting them change
+// its fields.
+// Returns the errors of the hooks that failed, joined.
+func (l *Logger) fireHooks(e *record) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		if h.levels&(1<<e.level) == 0 {
+			continue
+		}
+
+		fields := make(Fields, len(e.fields))
+		for k, v := range e.fields {
+			fields[k] = v
+		}
+		if l.queueHook(hookJob{h.hook, e.level, e.message, fields}) {
+			continue
+		}
+		errs = append(errs, h.hook.Fire(e.level, e.message, fields))
+		e.fields = fields
+	}
+	return joinErrors(errs...)
+}
+
+// A hook call queued for the hook workers.
+type hookJob struct {
+	hook    Hook
+	level   int
+	message string
+	fields  Fields
+}
+
+// Calls the hooks on n goroutines, see SetHookWorkers.
+func (l *Logger) SetHookWorkers(n int) {
+	l.stopHooks()
+	l.hookMu.Lock()
+	l.hookWorkers = n
+	l.hookMu.Unlock()
+	l.startHooks()
+}
+
+// Sets the behavior of the hook workers when their queue is full, see
+// SetHookOverflow.
+func (l *Logger) SetHookOverflow(mode int) {
+	l.hookMu.Lock()
+	l.hookOverflow = mode
+	l.hookMu.Unlock()
+}
+
+// Starts the hook workers, if enabled and not running.
+func (l *Logger) startHooks() {
+	l.hookMu.Lock()
+	defer l.hookMu.Unlock()
+
+	if l.hookWorkers <= 0 || l.hookQueue != nil {
+		return
+	}
+
+	queue := make(chan hookJob, HOOK_QUEUE)
+	done := &sync.WaitGroup{}
+	l.hookQueue, l.hookDone = queue, done
+	for i := 0; i < l.hookWorkers; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for j := range queue {
+				if err := j.hook.Fire(j.level, j.message, j.fields); err != nil {
+					l.mu.Lock()
+					l.asyncErr = err
+					l.mu.Unlock()
+				}
+			}
+		}()
+	}
+}
+
+// Stops the hook workers, once the queued calls are done.
+func (l *Logger) stopHooks() {
+	l.hookMu.Lock()
+	queue, done := l.hookQueue, l.hookDone
+	l.hookQueue, l.hookDone = nil, nil
+	if queue != nil {
+		close(queue)
+	}
+	l.hookMu.Unlock()
+
+	if done != nil {
+		done.Wait()
+	}
+}
+
+// Queues a hook call if the hook workers are running.
+// Returns false if the hook must be called synchronously.
+func (l *Logger) queueHook(j hookJob) bool {
+	l.hookMu.RLock()
+	defer l.hookMu.RUnlock()
+
+	if l.hookQueue == nil {
+		return false
+	}
+	if l.hookOverflow != ASYNC_DROP {
+		l.hookQueue <- j
+		return true
+	}
+	select {
+	case l.hookQueue <- j:
+	default:
+		l.mu.Lock()
+		l.suppressed++
+		l.mu.Unlock()
+	}
+	return true
+}
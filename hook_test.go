@@ -0,0 +1,84 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// A hook counting its calls once released.
+type blockedHook struct {
+	release chan struct{}
+	calls   int32
+}
+
+func (h *blockedHook) Fire(level int, message string, fields Fields) error {
+	<-h.release
+	atomic.AddInt32(&h.calls, 1)
+	return nil
+}
+
+func TestHookWorkers(t *testing.T) {
+	l, err := NewFile(filepath.Join(t.TempDir(), "test.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &blockedHook{release: make(chan struct{})}
+	l.AddHook(h)
+	l.SetHookWorkers(2)
+
+	// Logging must not wait for the blocked hook.
+	for i := 0; i < 10; i++ {
+		l.Err("message")
+	}
+	if n := atomic.LoadInt32(&h.calls); n != 0 {
+		t.Fatalf("%d hook calls done while blocked", n)
+	}
+
+	close(h.release)
+	l.Close()
+	if n := atomic.LoadInt32(&h.calls); n != 10 {
+		t.Fatalf("got %d hook calls after Close, want 10", n)
+	}
+}
+
+func TestHookWorkersDrop(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	h := &blockedHook{release: make(chan struct{})}
+	l.AddHook(h)
+	l.SetHookOverflow(ASYNC_DROP)
+	l.SetHookWorkers(1)
+
+	for i := 0; i < HOOK_QUEUE+10; i++ {
+		l.Err("message")
+	}
+	close(h.release)
+	l.stopHooks()
+	if n := atomic.LoadInt32(&h.calls); n >= HOOK_QUEUE+10 || n < HOOK_QUEUE {
+		t.Fatalf("got %d hook calls, want the queue size plus at most one", n)
+	}
+	if l.suppressed == 0 {
+		t.Fatal("dropped hook calls not counted as suppressed")
+	}
+}
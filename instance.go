@@ -0,0 +1,2038 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
+)
+
+// An independent logger, with its own Syslog writer and settings.
+// The package-level functions use a default Logger started by Open.
+// A Logger is safe for concurrent use: messages can be logged from several
+// goroutines while its settings, such as the levels, the outputs, the colors,
+// the format or the hooks, are changed.
+type Logger struct {
+	mu           sync.Mutex
+	s            syslogWriter
+	sMu          sync.RWMutex
+	network      string
+	raddr        string
+	priority     Priority
+	tag          string
+	hostname     string
+	file         *rotatingFile
+	out          io.Writer
+	errOut       io.Writer
+	debug        bool
+	mirror       bool
+	verbose      bool
+	defaultLevel int
+	level        int
+	screenLevel  int
+	syslogLevel  int
+	color        bool
+	colorOff     bool
+	style        int
+	compact      bool
+	format       int
+	timeFormat   string
+	messageKey   string
+	timeKey      string
+	levelKey     string
+	emfNS        string
+	emfMetrics   map[string]bool
+	emfDims      []string
+	utc          bool
+	now          func() time.Time
+	colorStyle   int
+	stderrLevel  int
+	caller       bool
+	callerSkip   int
+	callerStyle  int
+	errorStack   bool
+	maxBytes     int
+	exitCode     int
+	hook         func(level int, message string)
+	hooks        []levelHook
+	redactions   []*regexp.Regexp
+	redactedKeys map[string]bool
+	components   map[string]int
+	sinks        []Sink
+	ctxFields    []contextField
+	subscribers  []chan Event
+	eventDrops   uint64
+	dryRun       bool
+	dryOutput    []string
+	lifecycle    bool
+	version      string
+	commit       string
+	corrOn       bool
+	corrID       string
+	started      time.Time
+
+	// Symbols used by the symbol styles, indexed by level
+	symbols [8]string
+
+	// Colors replacing the default ones, indexed by level
+	colors [8]string
+
+	// Messages logged, indexed by level, updated atomically
+	counts [8]uint64
+
+	// Asynchronous mode, with the queue guarded by queueMu
+	asyncSize     int
+	asyncOverflow int
+	queueMu       sync.RWMutex
+	queue         chan queued
+	queueDone     chan struct{}
+	asyncErr      error
+
+	// Hook workers, with the queue guarded by hookMu
+	hookWorkers  int
+	hookOverflow int
+	hookMu       sync.RWMutex
+	hookQueue    chan hookJob
+	hookDone     *sync.WaitGroup
+
+	// Result of the first Close
+	closeOnce sync.Once
+	closeErr  error
+
+	// Messages logged before Open
+	bufferSize int
+	buffered   []record
+
+	// Sampling, indexed by level
+	bursts [8]*burst
+
+	// Levels forced for LogKeyed call sites
+	forced map[string]int
+
+	// Rate limiting, as a bucket of tokens refilled rateLimit times per
+	// second up to rateBurst
+	rateLimit   int
+	rateBurst   int
+	tokens      float64
+	lastRefill  time.Time
+	rateDropped int
+	rateTotal   uint64
+
+	// Deduplication of consecutive messages
+	dedup       bool
+	lastLevel   int
+	lastMessage string
+	repeats     int
+	dedupTimer  *time.Timer
+
+	// Suppressed messages since the last report
+	suppressed     int
+	reportInterval time.Duration
+	reportStop     chan struct{}
+}
+
+// Sampling state of a level. After the first occurrences, either one in
+// every occurrences or a random fraction thereafter of them pass.
+type burst struct {
+	first      int
+	every      int
+	thereafter float64
+	seen       map[string]int
+	start      time.Time
+}
+
+// Interval after which the sampling forgets the messages seen, and number of
+// distinct messages it counts at most before forgetting them earlier, so
+// that messages containing IDs or times do not grow it without limit.
+const (
+	sampleWindow = time.Minute
+	sampleKeys   = 10000
+)
+
+// Delay after which the repeats of a message are logged.
+const dedupInterval = 5 * time.Second
+
+// Import path of the package, used to skip its frames in callerFrame.
+var pkgPath = reflect.TypeOf(Logger{}).PkgPath()
+
+// Prefixes of the functions skipped by callerFrame: those of the package and
+// of the standard loggers it adapts, see StdLogger and NewSlogHandler.
+var internalFrames = []string{pkgPath + ".", "log.", "log/slog."}
+
+// A message to be logged.
+type record struct {
+	level      int
+	time       time.Time
+	message    string
+	fields     Fields
+	colored    string
+	color      string
+	screenOnly bool
+	syslogOnly bool
+	internal   bool
+	sync       bool
+	component  string
+	pc         uintptr
+	frame      runtime.Frame
+}
+
+// Adds text in front of the message and of its colored form.
+func (e *record) prepend(s string) {
+	e.message = s + e.message
+	if e.colored != "" {
+		e.colored = s + e.colored
+	}
+}
+
+// Creates a new logger and starts its logging system.
+// Takes a tag parameter to specify the name of the program, and options such
+// as WithDebug applied before starting.
+// Returns an error if unable to start logging.
+func New(tag string, opts ...Option) (*Logger, error) {
+	return NewRemote("", "", tag, opts...)
+}
+
+// Creates a new logger sending messages to a remote Syslog server, see
+// OpenRemote.
+// Returns an error if unable to start logging.
+func NewRemote(network, raddr, tag string, opts ...Option) (*Logger, error) {
+	l := newLogger()
+	o := applyOptions(opts)
+	o.configure(l)
+	if err := l.open(network, raddr, o.priority, tag); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Creates a new logger sending messages to the systemd journal, see
+// OpenJournald.
+// Returns an error if unable to start logging.
+func NewJournald(tag string, opts ...Option) (*Logger, error) {
+	return NewRemote(journaldNetwork, "", tag, opts...)
+}
+
+// Creates a new logger writing to a file instead of Syslog, see OpenFile.
+// Returns an error if unable to open the file.
+func NewFile(path string, maxBytes int64, opts ...Option) (*Logger, error) {
+	l := newLogger()
+	applyOptions(opts).configure(l)
+	if err := l.openFile(path, maxBytes); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Creates a new logger writing to a file with the given rotation settings,
+// see OpenFileWithOptions.
+// Returns an error if unable to open the file.
+func NewFileWithOptions(path string, fileOpts FileOptions, opts ...Option) (*Logger, error) {
+	l := newLogger()
+	applyOptions(opts).configure(l)
+	if err := l.openFileWithOptions(path, fileOpts); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Creates a logger with the default settings, without starting it.
+func newLogger() *Logger {
+	return &Logger{
+		out:          os.Stderr,
+		errOut:       os.Stderr,
+		defaultLevel: L_NOTICE,
+		level:        L_DEBUG,
+		screenLevel:  -1,
+		syslogLevel:  -1,
+		color:        true,
+		style:        STYLE_TEXT,
+		timeFormat:   TIME_DEFAULT,
+		messageKey:   "msg",
+		timeKey:      "time",
+		levelKey:     "level",
+		now:          time.Now,
+		colorStyle:   COLOR_BOTH,
+		stderrLevel:  -1,
+		exitCode:     1,
+		symbols:      defaultSymbols,
+		forced:       make(map[string]int),
+	}
+}
+
+// Starts the logging system of the logger.
+// An empty network uses the local Syslog server.
+func (l *Logger) open(network, raddr string, priority Priority, tag string) error {
+	if tag != "" {
+		l.mu.Lock()
+		hostname := l.hostname
+		l.mu.Unlock()
+
+		s, err := dial(network, raddr, priority, tag, hostname)
+		if err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		l.s = s
+		l.network, l.raddr, l.priority, l.tag = network, raddr, priority, tag
+		l.mu.Unlock()
+	} else {
+		return errors.New("logger: tag cannot be empty")
+	}
+
+	l.start()
+	return nil
+}
+
+// Sets the hostname sent to remote Syslog servers, see SetHostname.
+func (l *Logger) SetHostname(name string) {
+	l.mu.Lock()
+	l.hostname = name
+	l.mu.Unlock()
+}
+
+// Reconnects to Syslog with a new tag, see SetTag.
+func (l *Logger) SetTag(tag string) error {
+	if tag == "" {
+		return errors.New("logger: tag cannot be empty")
+	}
+
+	l.mu.Lock()
+	opened := l.s != nil
+	network, raddr, priority, hostname := l.network, l.raddr, l.priority, l.hostname
+	l.mu.Unlock()
+	if !opened {
+		return ErrNotOpened
+	}
+
+	s, err := dial(network, raddr, priority, tag, hostname)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the messages being sent to the old writer, so that it is not
+	// closed under them and does not reconnect afterwards.
+	l.sMu.Lock()
+	defer l.sMu.Unlock()
+	l.mu.Lock()
+	old := l.s
+	l.s, l.tag = s, tag
+	l.mu.Unlock()
+	return old.Close()
+}
+
+// Starts the logging system of the logger, writing to a file instead of
+// Syslog.
+func (l *Logger) openFile(path string, maxBytes int64) error {
+	f, err := openRotatingFile(path, maxBytes, FILE_BACKUPS)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.file = f
+	l.mu.Unlock()
+
+	l.start()
+	return nil
+}
+
+// Starts the logging system of the logger, writing to a file with the given
+// rotation settings.
+func (l *Logger) openFileWithOptions(path string, opts FileOptions) error {
+	f, err := openRotatingFileWithOptions(path, opts)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.file = f
+	l.mu.Unlock()
+
+	l.start()
+	return nil
+}
+
+// Finishes starting the logging system once its destination is open.
+// Messages buffered before that which cannot be sent are reported with a
+// warning, as opening succeeded anyway.
+func (l *Logger) start() {
+	l.closeOnce = sync.Once{}
+	l.closeErr = nil
+
+	l.mu.Lock()
+	if l.reportStop == nil {
+		l.startReports()
+	}
+	if l.corrOn && l.corrID == "" {
+		l.corrID = NewCorrelationID()
+	}
+	if !colorCapable(l.out) || !colorCapable(l.errOut) {
+		l.color = false
+	}
+	l.mu.Unlock()
+
+	l.startAsync()
+	l.startHooks()
+	if err := l.flushBuffered(); err != nil {
+		l.write(record{level: L_WARNING, message: "unable to send the messages logged before opening: " + err.Error(), internal: true})
+	}
+
+	l.mu.Lock()
+	l.started = l.now()
+	lifecycle, version, commit := l.lifecycle, l.version, l.commit
+	l.mu.Unlock()
+	if lifecycle {
+		host, _ := os.Hostname()
+		m := fmt.Sprintf("process starting (pid %d, host %s", os.Getpid(), host)
+		if version != "" {
+			m += ", version " + version
+		}
+		if commit != "" {
+			m += ", commit " + commit
+		}
+		l.Notice(m + ")")
+	}
+}
+
+// Stops the logging system of the logger.
+// Calling it again, or without the logging system being started, has no
+// effect. Repeated calls return the result of the first call.
+// Returns an error if unable to stop logging.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		opened, lifecycle := l.s != nil || l.file != nil, l.lifecycle
+		uptime := l.now().Sub(l.started).Round(time.Second)
+		l.mu.Unlock()
+		if !opened {
+			l.stopHooks()
+			return
+		}
+
+		if lifecycle {
+			l.Notice(fmt.Sprintf("process stopping (uptime %s)", uptime))
+		}
+		l.flushRepeats()
+		l.stopAsync()
+		l.stopHooks()
+
+		var errs []error
+		l.sMu.Lock()
+		l.mu.Lock()
+		if l.file != nil {
+			errs = append(errs, l.file.Close())
+			l.file = nil
+		}
+		if l.s != nil {
+			errs = append(errs, l.s.Close())
+			l.s = nil
+		}
+		for _, s := range l.sinks {
+			if c, ok := s.(io.Closer); ok {
+				errs = append(errs, c.Close())
+			}
+		}
+		l.mu.Unlock()
+		l.sMu.Unlock()
+		l.closeErr = joinErrors(errs...)
+		l.reset()
+	})
+	return l.closeErr
+}
+
+// Clears the state kept while logging, so that the logging system starts
+// clean if opened again. Settings are kept.
+func (l *Logger) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buffered = nil
+	l.bufferSize = 0
+	l.tokens, l.lastRefill, l.rateDropped = 0, time.Time{}, 0
+	l.lastLevel, l.lastMessage, l.repeats = -1, "", 0
+	if l.dedupTimer != nil {
+		l.dedupTimer.Stop()
+		l.dedupTimer = nil
+	}
+	if l.reportStop != nil {
+		close(l.reportStop)
+		l.reportStop = nil
+	}
+	l.suppressed = 0
+	for _, b := range l.bursts {
+		if b != nil {
+			b.seen = make(map[string]int)
+		}
+	}
+}
+
+// Sets the writer messages are printed to, see SetOutput.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = w
+	l.detectColor()
+}
+
+// Sets the writer all messages are printed to, see SetScreenOutput.
+func (l *Logger) SetScreenOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = w
+	l.errOut = w
+	l.detectColor()
+}
+
+// Sets the writer error messages are printed to, see SetErrorOutput.
+func (l *Logger) SetErrorOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errOut = w
+	l.detectColor()
+}
+
+// Enables colors if the outputs support them, unless disabled with
+// DisableColor. Must be called with the lock held.
+func (l *Logger) detectColor() {
+	l.color = !l.colorOff && colorCapable(l.out) && colorCapable(l.errOut)
+}
+
+// Disables colors in messages printed to screen.
+func (l *Logger) DisableColor() {
+	l.mu.Lock()
+	l.color = false
+	l.colorOff = true
+	l.mu.Unlock()
+}
+
+// Enables colors in messages printed to screen if the outputs support them,
+// see EnableColor.
+func (l *Logger) EnableColor() {
+	l.mu.Lock()
+	l.colorOff = false
+	l.detectColor()
+	l.mu.Unlock()
+}
+
+// Sets how colors are rendered on screen, see SetColorStyle.
+func (l *Logger) SetColorStyle(cs int) {
+	l.mu.Lock()
+	l.colorStyle = cs
+	l.mu.Unlock()
+}
+
+// Sets the format of messages printed to screen, see SetFormat.
+func (l *Logger) SetFormat(f int) {
+	l.mu.Lock()
+	l.format = f
+	l.mu.Unlock()
+}
+
+// Sets the layout of timestamps on screen, see SetTimeFormat.
+func (l *Logger) SetTimeFormat(layout string) {
+	l.mu.Lock()
+	l.timeFormat = layout
+	l.mu.Unlock()
+}
+
+// Sets the key of the message in the JSON format, see SetMessageKey.
+func (l *Logger) SetMessageKey(key string) {
+	if key == "" {
+		key = "msg"
+	}
+	l.mu.Lock()
+	l.messageKey = key
+	l.mu.Unlock()
+}
+
+// Sets the key of the time in the JSON format, see SetTimeKey.
+func (l *Logger) SetTimeKey(key string) {
+	if key == "" {
+		key = "time"
+	}
+	l.mu.Lock()
+	l.timeKey = key
+	l.mu.Unlock()
+}
+
+// Sets the key of the level in the JSON format, see SetLevelKey.
+func (l *Logger) SetLevelKey(key string) {
+	if key == "" {
+		key = "level"
+	}
+	l.mu.Lock()
+	l.levelKey = key
+	l.mu.Unlock()
+}
+
+// Prints timestamps in UTC instead of local time, see SetTimeUTC.
+func (l *Logger) SetTimeUTC(b bool) {
+	l.mu.Lock()
+	l.utc = b
+	l.mu.Unlock()
+}
+
+// Sets the function giving the current time, see SetTimeSource.
+func (l *Logger) SetTimeSource(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	l.mu.Lock()
+	l.now = now
+	l.mu.Unlock()
+}
+
+// Adds the location of the caller to messages, see SetCaller.
+func (l *Logger) SetCaller(b bool) {
+	l.mu.Lock()
+	l.caller = b
+	l.mu.Unlock()
+}
+
+// Limits the size of messages, see SetMaxMessageBytes.
+func (l *Logger) SetMaxMessageBytes(n int) {
+	l.mu.Lock()
+	l.maxBytes = n
+	l.mu.Unlock()
+}
+
+// Skips frames when looking for the caller, see SetCallerSkip.
+func (l *Logger) SetCallerSkip(skip int) {
+	l.mu.Lock()
+	l.callerSkip = skip
+	l.mu.Unlock()
+}
+
+// Sets how the caller is shown, see SetCallerStyle.
+func (l *Logger) SetCallerStyle(style int) {
+	l.mu.Lock()
+	l.callerStyle = style
+	l.mu.Unlock()
+}
+
+// Sets a function called for each message logged, see SetHook.
+func (l *Logger) SetHook(hook func(level int, message string)) {
+	l.mu.Lock()
+	l.hook = hook
+	l.mu.Unlock()
+}
+
+// Sets the level echoed to stderr, see SetDaemonStderrLevel.
+func (l *Logger) SetDaemonStderrLevel(level int) {
+	l.mu.Lock()
+	l.stderrLevel = level
+	l.mu.Unlock()
+}
+
+// Sets the logger to dry run mode, see SetDryRun.
+func (l *Logger) SetDryRun(b bool) {
+	l.mu.Lock()
+	l.dryRun = b
+	if b {
+		l.dryOutput = nil
+	}
+	l.mu.Unlock()
+}
+
+// Returns the lines captured in dry run mode, without colors.
+func (l *Logger) DryRunOutput() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.dryOutput...)
+}
+
+// Sets the least severe level logged when verbose is off, see
+// SetDefaultLevel.
+func (l *Logger) SetDefaultLevel(level int) {
+	l.mu.Lock()
+	l.defaultLevel = level
+	l.mu.Unlock()
+}
+
+// Sets the least severe level logged, see SetLevel.
+func (l *Logger) SetLevel(level int) {
+	l.mu.Lock()
+	l.level = level
+	l.screenLevel, l.syslogLevel = -1, -1
+	l.mu.Unlock()
+}
+
+// Sets the least severe level printed on screen, see SetScreenLevel.
+func (l *Logger) SetScreenLevel(level int) {
+	l.mu.Lock()
+	l.screenLevel = level
+	l.mu.Unlock()
+}
+
+// Sets the least severe level sent to Syslog, see SetSyslogLevel.
+func (l *Logger) SetSyslogLevel(level int) {
+	l.mu.Lock()
+	l.syslogLevel = level
+	l.mu.Unlock()
+}
+
+// Logs a Notice when the logger starts and stops, see SetLifecycleLogs.
+func (l *Logger) SetLifecycleLogs(b bool) {
+	l.mu.Lock()
+	l.lifecycle = b
+	l.mu.Unlock()
+}
+
+// Sets the version and commit of the program, see SetVersion.
+func (l *Logger) SetVersion(v string, c string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.version = v
+	l.commit = c
+}
+
+// Sets the style of the header of messages printed to screen, see SetStyle.
+func (l *Logger) SetStyle(st int) {
+	l.mu.Lock()
+	l.style = st
+	l.mu.Unlock()
+}
+
+// Omits the padding of level names on screen, see SetCompact.
+func (l *Logger) SetCompact(b bool) {
+	l.mu.Lock()
+	l.compact = b
+	l.mu.Unlock()
+}
+
+// Replaces the symbols used by the symbol styles, see SetSymbols.
+func (l *Logger) SetSymbols(m map[int]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for level, symbol := range m {
+		if level >= L_EMERGENCY && level <= L_DEBUG {
+			l.symbols[level] = symbol
+		}
+	}
+}
+
+// Returns the number of messages logged per level, see Stats.
+func (l *Logger) Stats() map[int]uint64 {
+	stats := make(map[int]uint64, len(l.counts))
+	for level := range l.counts {
+		stats[level] = atomic.LoadUint64(&l.counts[level])
+	}
+	return stats
+}
+
+// Returns the number of messages logged at a level, see Count.
+func (l *Logger) Count(level int) uint64 {
+	if level < L_EMERGENCY || level > L_DEBUG {
+		return 0
+	}
+	return atomic.LoadUint64(&l.counts[level])
+}
+
+// Sets the number of messages logged at every level back to zero, see
+// ResetStats.
+func (l *Logger) ResetStats() {
+	for level := range l.counts {
+		atomic.StoreUint64(&l.counts[level], 0)
+	}
+}
+
+// Replaces the color of a level, see SetLevelColor.
+func (l *Logger) SetLevelColor(level int, ansi string) {
+	if level >= L_EMERGENCY && level <= L_DEBUG {
+		l.mu.Lock()
+		l.colors[level] = ansi
+		l.mu.Unlock()
+	}
+}
+
+// Restores the default colors of all levels, see ResetColors.
+func (l *Logger) ResetColors() {
+	l.mu.Lock()
+	l.colors = [8]string{}
+	l.mu.Unlock()
+}
+
+// Limits the number of messages logged per second, see SetRateLimit.
+func (l *Logger) SetRateLimit(maxPerSecond int) {
+	l.mu.Lock()
+	l.rateLimit = maxPerSecond
+	l.mu.Unlock()
+}
+
+// Allows bursts of messages beyond the rate limit, see SetRateLimitBurst.
+func (l *Logger) SetRateLimitBurst(burst int) {
+	l.mu.Lock()
+	l.rateBurst = burst
+	l.mu.Unlock()
+}
+
+// Returns the number of messages dropped by the rate limit, see
+// RateLimited.
+func (l *Logger) RateLimited() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rateTotal
+}
+
+// Collapses consecutive identical messages, see SetDedup.
+func (l *Logger) SetDedup(b bool) {
+	l.mu.Lock()
+	l.dedup = b
+	l.lastLevel, l.lastMessage, l.repeats = -1, "", 0
+	if l.dedupTimer != nil {
+		l.dedupTimer.Stop()
+		l.dedupTimer = nil
+	}
+	l.mu.Unlock()
+}
+
+// Samples messages of the given level, see SetSampleBurst.
+func (l *Logger) SetSampleBurst(level int, first int, thereafter float64) {
+	if level < L_EMERGENCY || level > L_DEBUG {
+		return
+	}
+
+	l.mu.Lock()
+	if thereafter >= 1 {
+		l.bursts[level] = nil
+	} else {
+		l.bursts[level] = &burst{first: first, thereafter: thereafter, seen: make(map[string]int)}
+	}
+	l.mu.Unlock()
+}
+
+// Samples messages of the given level deterministically, see SetSampling.
+func (l *Logger) SetSampling(level int, first int, every int) {
+	if level < L_EMERGENCY || level > L_DEBUG {
+		return
+	}
+
+	l.mu.Lock()
+	if every <= 1 {
+		l.bursts[level] = nil
+	} else {
+		l.bursts[level] = &burst{first: first, every: every, seen: make(map[string]int)}
+	}
+	l.mu.Unlock()
+}
+
+// Reports suppressed messages every d, see SetSuppressionReportInterval.
+func (l *Logger) SetSuppressionReportInterval(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reportInterval = d
+	l.startReports()
+}
+
+// Starts or restarts the reports of suppressed messages, stopping them if
+// the report interval is not positive. Must be called with the lock held.
+func (l *Logger) startReports() {
+	if l.reportStop != nil {
+		close(l.reportStop)
+		l.reportStop = nil
+	}
+
+	d := l.reportInterval
+	if d <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	l.reportStop = stop
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.mu.Lock()
+				n := l.suppressed
+				l.suppressed = 0
+				l.mu.Unlock()
+				if n > 0 {
+					l.write(record{level: L_NOTICE, message: fmt.Sprintf("suppressed %d messages in the last %s", n, d), internal: true})
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Sets the logger to debug mode, see SetDebug.
+func (l *Logger) SetDebug(b bool) {
+	l.mu.Lock()
+	l.debug = b
+	l.mu.Unlock()
+}
+
+// Sets the logger to mirror mode, see SetMirror.
+func (l *Logger) SetMirror(b bool) {
+	l.mu.Lock()
+	l.mirror = b
+	l.mu.Unlock()
+}
+
+// Sets the logger to verbose mode, see SetVerbose.
+func (l *Logger) SetVerbose(b bool) {
+	l.mu.Lock()
+	l.verbose = b
+	l.mu.Unlock()
+}
+
+// Returns the current configuration of the logger.
+func (l *Logger) Snapshot() Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Config{
+		Debug:     l.debug,
+		Mirror:    l.mirror,
+		Verbose:   l.verbose,
+		Default:   l.defaultLevel,
+		Level:     l.level,
+		Screen:    l.screenLevel,
+		Syslog:    l.syslogLevel,
+		Color:     l.color,
+		Style:     l.style,
+		Compact:   l.compact,
+		Format:    l.format,
+		Time:      l.timeFormat,
+		UTC:       l.utc,
+		ColorMode: l.colorStyle,
+		Symbols:   l.symbols,
+		Lifecycle: l.lifecycle,
+		Stderr:    l.stderrLevel,
+		Out:       l.out,
+		ErrOut:    l.errOut,
+		Sinks:     append([]Sink(nil), l.sinks...),
+		Colors:    l.colors,
+		Caller:    l.caller,
+		Skip:      l.callerSkip,
+		CallStyle: l.callerStyle,
+		MaxBytes:  l.maxBytes,
+		RateLimit: l.rateLimit,
+		RateBurst: l.rateBurst,
+		Dedup:     l.dedup,
+		NoColor:   l.colorOff,
+		MsgKey:    l.messageKey,
+		TimeKey:   l.timeKey,
+		LevelKey:  l.levelKey,
+	}
+}
+
+// Applies a configuration to the logger, typically obtained from Snapshot.
+func (l *Logger) Apply(c Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debug = c.Debug
+	l.mirror = c.Mirror
+	l.verbose = c.Verbose
+	l.defaultLevel = c.Default
+	l.level = c.Level
+	l.screenLevel = c.Screen
+	l.syslogLevel = c.Syslog
+	if c.Out != nil {
+		l.out = c.Out
+	}
+	if c.ErrOut != nil {
+		l.errOut = c.ErrOut
+	}
+	l.colorOff = c.NoColor
+	l.color = c.Color && !l.colorOff && colorCapable(l.out) && colorCapable(l.errOut)
+	l.style = c.Style
+	l.compact = c.Compact
+	l.format = c.Format
+	l.timeFormat = c.Time
+	l.messageKey, l.timeKey, l.levelKey = c.MsgKey, c.TimeKey, c.LevelKey
+	if l.messageKey == "" {
+		l.messageKey = "msg"
+	}
+	if l.timeKey == "" {
+		l.timeKey = "time"
+	}
+	if l.levelKey == "" {
+		l.levelKey = "level"
+	}
+	l.utc = c.UTC
+	l.colorStyle = c.ColorMode
+	l.symbols = c.Symbols
+	l.lifecycle = c.Lifecycle
+	l.stderrLevel = c.Stderr
+	l.sinks = append([]Sink(nil), c.Sinks...)
+	l.colors = c.Colors
+	l.caller = c.Caller
+	l.callerSkip = c.Skip
+	l.callerStyle = c.CallStyle
+	l.maxBytes = c.MaxBytes
+	l.rateLimit = c.RateLimit
+	l.rateBurst = c.RateBurst
+	if c.Dedup != l.dedup {
+		l.dedup = c.Dedup
+		l.lastLevel, l.lastMessage, l.repeats = -1, "", 0
+		if l.dedupTimer != nil {
+			l.dedupTimer.Stop()
+			l.dedupTimer = nil
+		}
+	}
+}
+
+// Logs an Emergency-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Emerg(message string) error {
+	return l.output(L_EMERGENCY, message)
+}
+
+// Logs an Alert-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Alert(message string) error {
+	return l.output(L_ALERT, message)
+}
+
+// Logs a Critical-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Crit(message string) error {
+	return l.output(L_CRITICAL, message)
+}
+
+// Logs an Error-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Err(message string) error {
+	return l.output(L_ERROR, message)
+}
+
+// Logs a Warning-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Warning(message string) error {
+	return l.output(L_WARNING, message)
+}
+
+// Logs a Notice-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Notice(message string) error {
+	return l.output(L_NOTICE, message)
+}
+
+// Logs an Info-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Info(message string) error {
+	return l.output(L_INFO, message)
+}
+
+// Logs a Debug-level event.
+// Returns an error if unable to log it.
+func (l *Logger) Debug(message string) error {
+	return l.output(L_DEBUG, message)
+}
+
+// Logs an Emergency-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Emergencyf(format string, a ...interface{}) error {
+	return l.output(L_EMERGENCY, fmt.Sprintf(format, a...))
+}
+
+// Logs an Alert-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Alertf(format string, a ...interface{}) error {
+	return l.output(L_ALERT, fmt.Sprintf(format, a...))
+}
+
+// Logs a Critical-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Criticalf(format string, a ...interface{}) error {
+	return l.output(L_CRITICAL, fmt.Sprintf(format, a...))
+}
+
+// Logs an Error-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Errorf(format string, a ...interface{}) error {
+	return l.output(L_ERROR, fmt.Sprintf(format, a...))
+}
+
+// Logs a Warning-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Warningf(format string, a ...interface{}) error {
+	return l.output(L_WARNING, fmt.Sprintf(format, a...))
+}
+
+// Logs a Notice-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Noticef(format string, a ...interface{}) error {
+	return l.output(L_NOTICE, fmt.Sprintf(format, a...))
+}
+
+// Logs an Info-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Infof(format string, a ...interface{}) error {
+	return l.output(L_INFO, fmt.Sprintf(format, a...))
+}
+
+// Logs a Debug-level event, formatted according to a format specifier.
+// Returns an error if unable to log it.
+func (l *Logger) Debugf(format string, a ...interface{}) error {
+	return l.output(L_DEBUG, fmt.Sprintf(format, a...))
+}
+
+// Logs a Critical-level event, stops the logger and exits, see Fatal.
+func (l *Logger) Fatal(message string) {
+	l.write(record{level: L_CRITICAL, message: message, sync: true, internal: true})
+	l.Close()
+	os.Exit(l.exitStatus())
+}
+
+// Sets the exit status used by Fatal, see SetExitCode.
+func (l *Logger) SetExitCode(code int) {
+	l.mu.Lock()
+	l.exitCode = code
+	l.mu.Unlock()
+}
+
+// Returns the exit status used by Fatal.
+func (l *Logger) exitStatus() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exitCode
+}
+
+// Logs a formatted Critical-level event, stops the logger and exits, see
+// Fatal.
+func (l *Logger) Fatalf(format string, a ...interface{}) {
+	l.Fatal(fmt.Sprintf(format, a...))
+}
+
+// Logs a Critical-level event and panics, see Panic.
+func (l *Logger) Panic(message string) {
+	l.write(record{level: L_CRITICAL, message: message, sync: true, internal: true})
+	panic(message)
+}
+
+// Logs a formatted Critical-level event and panics, see Panic.
+func (l *Logger) Panicf(format string, a ...interface{}) {
+	l.Panic(fmt.Sprintf(format, a...))
+}
+
+// Logs a message with per-message options, see LogOpt.
+func (l *Logger) LogOpt(opts LogOptions) error {
+	return l.write(record{
+		level:      opts.Level,
+		message:    opts.Message,
+		color:      opts.Color,
+		screenOnly: opts.ScreenOnly,
+		syslogOnly: opts.SyslogOnly,
+		fields:     opts.Fields,
+		sync:       opts.Sync,
+	})
+}
+
+// Logs a Notice-level change of a value, see LogChange.
+func (l *Logger) LogChange(name string, before, after interface{}) error {
+	return l.write(record{
+		level:   L_NOTICE,
+		message: fmt.Sprintf("%s: %v → %v", name, before, after),
+		colored: fmt.Sprintf("%s: %s%v%s → %s%v%s", name, C_RED, before, C_RESET, C_GREEN, after, C_RESET),
+	})
+}
+
+// Logs a message at the given level or at the level forced for key, see
+// LogKeyed.
+func (l *Logger) LogKeyed(key string, level int, message string) error {
+	l.mu.Lock()
+	if f, ok := l.forced[key]; ok {
+		level = f
+	}
+	l.mu.Unlock()
+
+	return l.output(level, message)
+}
+
+// Forces the level of messages logged with LogKeyed under key, see
+// ForceLevel.
+func (l *Logger) ForceLevel(key string, level int) {
+	l.mu.Lock()
+	if level < 0 {
+		delete(l.forced, key)
+	} else {
+		l.forced[key] = level
+	}
+	l.mu.Unlock()
+}
+
+// Writes a message to w formatted the same way as on screen, see
+// FprintLevel.
+func (l *Logger) FprintLevel(w io.Writer, level int, message string) error {
+	if !IsValidLevel(level) {
+		return ErrInvalidLevel
+	}
+	_, err := io.WriteString(w, l.formatLine(record{level: level, message: message}, l.colored() && colorCapable(w)))
+	return err
+}
+
+// Tells if colors are enabled.
+func (l *Logger) colored() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.color
+}
+
+// Logs a message at the given level.
+func (l *Logger) output(level int, message string) error {
+	return l.write(record{level: level, message: message})
+}
+
+// Routes a record to the screen and/or Syslog depending on its level, its
+// options and on the debug and verbose settings.
+// Returns ErrInvalidLevel for unknown levels.
+func (l *Logger) write(e record) error {
+	if !IsValidLevel(e.level) {
+		return ErrInvalidLevel
+	}
+	override, overridden := l.componentLevel(e.component)
+	if overridden {
+		if e.level != L_EMERGENCY && e.level > override {
+			return nil
+		}
+	} else if !l.enabled(e.level) {
+		return nil
+	}
+
+	if e.time.IsZero() {
+		l.mu.Lock()
+		e.time = l.now()
+		l.mu.Unlock()
+	}
+
+	if !e.internal && (!l.sampled(e) || l.rateLimited(e) || l.repeated(e)) {
+		l.mu.Lock()
+		l.suppressed++
+		l.mu.Unlock()
+		return nil
+	}
+
+	if e.level >= L_EMERGENCY && e.level <= L_DEBUG {
+		atomic.AddUint64(&l.counts[e.level], 1)
+	}
+
+	l.mu.Lock()
+	toScreen := l.debug || l.mirror || e.level <= L_ALERT
+	toSyslog := !l.debug || l.mirror || e.level <= L_ALERT
+	if e.screenOnly {
+		toScreen, toSyslog = true, false
+	} else if e.syslogOnly {
+		toScreen, toSyslog = false, true
+	}
+	if e.level != L_EMERGENCY && !overridden {
+		toScreen = toScreen && e.level <= l.destinationLevel(l.screenLevel)
+		toSyslog = toSyslog && e.level <= l.destinationLevel(l.syslogLevel)
+	}
+	caller, callerSkip, callerStyle, maxBytes := l.caller, l.callerSkip, l.callerStyle, l.maxBytes
+	hook, dryRun := l.hook, l.dryRun
+	version, commit, corrID := l.version, l.commit, l.corrID
+	_, journal := l.s.(*journalWriter)
+	l.mu.Unlock()
+
+	if version != "" || commit != "" {
+		e.fields = withBuild(e.fields, version, commit)
+	}
+	if corrID != "" {
+		e.fields = withCorrelationID(e.fields, corrID)
+	}
+
+	if e.component != "" {
+		e.prepend("[" + e.component + "] ")
+	}
+
+	if caller || journal {
+		if e.pc != 0 {
+			e.frame, _ = runtime.CallersFrames([]uintptr{e.pc}).Next()
+		} else {
+			e.frame = callerFrame(callerSkip)
+		}
+		if caller {
+			e.prepend(callerLocation(e.frame, callerStyle) + " ")
+		}
+	}
+
+	l.redact(&e)
+
+	if maxBytes > 0 && len(e.message) > maxBytes {
+		e.message = truncate(e.message, maxBytes)
+		e.colored = ""
+	}
+
+	hookErr := l.fireHooks(&e)
+
+	if hook != nil {
+		hook(e.level, e.message+formatFields(e.fields))
+	}
+
+	if dryRun {
+		line := l.formatLine(e, false)
+		l.mu.Lock()
+		l.dryOutput = append(l.dryOutput, strings.TrimSuffix(line, "\n"))
+		l.mu.Unlock()
+		return hookErr
+	}
+
+	if e.sync {
+		// Keep the order of the messages already queued.
+		l.drain()
+	} else if l.enqueue(e, toScreen, toSyslog) {
+		return hookErr
+	}
+	return joinErrors(hookErr, l.deliver(e, toScreen, toSyslog))
+}
+
+// Writes a message to the screen, Syslog, the subscribers and the sinks.
+// Returns the errors of all the outputs that failed, joined.
+func (l *Logger) deliver(e record, toScreen, toSyslog bool) error {
+	l.mu.Lock()
+	errOut, stderrLevel := l.errOut, l.stderrLevel
+	l.mu.Unlock()
+
+	var errs []error
+	if toScreen {
+		errs = append(errs, l.printToScreen(e))
+	} else if e.level <= stderrLevel {
+		_, err := fmt.Fprint(errOut, l.formatLine(e, false))
+		errs = append(errs, err)
+	}
+	if toSyslog {
+		errs = append(errs, l.sendToSyslog(e))
+	}
+
+	l.publish(e)
+
+	l.mu.Lock()
+	sinks := l.sinks
+	l.mu.Unlock()
+	for _, s := range sinks {
+		errs = append(errs, l.logToSink(s, e))
+	}
+
+	return joinErrors(errs...)
+}
+
+// Returns a copy of fields with the version and commit of the program added,
+// unless they are empty or already set.
+func withBuild(fields Fields, version, commit string) Fields {
+	f := make(Fields, len(fields)+2)
+	if version != "" {
+		f["version"] = version
+	}
+	if commit != "" {
+		f["commit"] = commit
+	}
+	for k, v := range fields {
+		f[k] = v
+	}
+	return f
+}
+
+// Returns the frame of the first caller outside of the package and of the
+// standard loggers, or of the caller skip frames above it, or an empty frame
+// if there is none. Walking the stack instead of using a fixed depth gives
+// the right call site whichever function was called.
+func callerFrame(skip int) runtime.Frame {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	outside := false
+	for {
+		f, more := frames.Next()
+		if outside || !internalFrame(f.Function) {
+			outside = true
+			if skip == 0 {
+				return f
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}
+		}
+	}
+}
+
+// Tells if a function belongs to the package or to the standard loggers.
+func internalFrame(function string) bool {
+	for _, p := range internalFrames {
+		if strings.HasPrefix(function, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the location of a frame in a caller style: its file, line and
+// function, as "file.go:123 main.handler", or only one of them.
+func callerLocation(f runtime.Frame, style int) string {
+	if f.File == "" {
+		return "???:0"
+	}
+	file := filepath.Base(f.File) + ":" + strconv.Itoa(f.Line)
+	switch style {
+	case CALLER_FILE:
+		return file
+	case CALLER_FUNC:
+		return filepath.Base(f.Function)
+	}
+	return file + " " + filepath.Base(f.Function)
+}
+
+// Tells if messages of the given level pass the level and verbose settings.
+func (l *Logger) enabled(level int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	least := max(l.destinationLevel(l.screenLevel), l.destinationLevel(l.syslogLevel))
+	return level == L_EMERGENCY || (level <= least && (l.verbose || level <= l.defaultLevel))
+}
+
+// Returns the least severe level of a destination, which follows the level
+// of the logger if not set. Must be called with the lock held.
+func (l *Logger) destinationLevel(level int) int {
+	if level < 0 {
+		return l.level
+	}
+	return level
+}
+
+// Tells if a record goes beyond the rate limit and must be dropped.
+// Each record takes a token from a bucket refilled at the rate limit, which
+// starts full. The first drop schedules a summary of the dropped messages a
+// second later. Emergency messages are never dropped.
+func (l *Logger) rateLimited(e record) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rateLimit <= 0 || e.level == L_EMERGENCY {
+		return false
+	}
+
+	now := l.now()
+	capacity := float64(max(l.rateBurst, l.rateLimit))
+	if l.lastRefill.IsZero() {
+		l.tokens = capacity
+	} else {
+		l.tokens = min(capacity, l.tokens+now.Sub(l.lastRefill).Seconds()*float64(l.rateLimit))
+	}
+	l.lastRefill = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return false
+	}
+
+	if l.rateDropped == 0 {
+		time.AfterFunc(time.Second, l.reportRateLimit)
+	}
+	l.rateDropped++
+	l.rateTotal++
+	return true
+}
+
+// Logs a Warning with the number of messages dropped by the rate limit.
+func (l *Logger) reportRateLimit() {
+	l.mu.Lock()
+	n := l.rateDropped
+	l.rateDropped = 0
+	l.mu.Unlock()
+
+	if n > 0 {
+		l.write(record{level: L_WARNING, message: fmt.Sprintf("suppressed %d messages", n), internal: true})
+	}
+}
+
+// Tells if a record repeats the previous one when deduplication is on.
+// Repeats are counted instead of logged, and the count is logged before the
+// next different message or when the deduplication timer fires.
+func (l *Logger) repeated(e record) bool {
+	l.mu.Lock()
+	if !l.dedup {
+		l.mu.Unlock()
+		return false
+	}
+
+	if e.level == l.lastLevel && e.message == l.lastMessage {
+		l.repeats++
+		if l.dedupTimer == nil {
+			l.dedupTimer = time.AfterFunc(dedupInterval, l.flushRepeats)
+		}
+		l.mu.Unlock()
+		return true
+	}
+
+	level, n := l.lastLevel, l.repeats
+	l.lastLevel, l.lastMessage, l.repeats = e.level, e.message, 0
+	if l.dedupTimer != nil {
+		l.dedupTimer.Stop()
+		l.dedupTimer = nil
+	}
+	l.mu.Unlock()
+
+	l.logRepeats(level, n)
+	return false
+}
+
+// Logs the count of repeats of the previous message, if any.
+func (l *Logger) flushRepeats() {
+	l.mu.Lock()
+	level, n := l.lastLevel, l.repeats
+	l.repeats = 0
+	l.dedupTimer = nil
+	l.mu.Unlock()
+
+	l.logRepeats(level, n)
+}
+
+// Logs that the previous message was repeated n times, if n is not zero.
+func (l *Logger) logRepeats(level int, n int) {
+	if n > 0 {
+		l.write(record{level: level, message: fmt.Sprintf("last message repeated %d times", n), internal: true})
+	}
+}
+
+// Tells if a record passes the sampling of its level.
+func (l *Logger) sampled(e record) bool {
+	if e.level < L_EMERGENCY || e.level > L_DEBUG {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bursts[e.level]
+	if b == nil {
+		return true
+	}
+
+	now := l.now()
+	if now.Sub(b.start) >= sampleWindow || (len(b.seen) >= sampleKeys && b.seen[e.message] == 0) {
+		b.seen = make(map[string]int)
+		b.start = now
+	}
+
+	b.seen[e.message]++
+	n := b.seen[e.message]
+	if n <= b.first {
+		return true
+	}
+	if b.every > 0 {
+		return (n-b.first)%b.every == 0
+	}
+	return rand.Float64() < b.thereafter
+}
+
+// Prints a record to the screen. Error-level and more severe messages are
+// printed to the error output.
+func (l *Logger) printToScreen(e record) error {
+	l.mu.Lock()
+	w, color := l.out, l.color
+	if e.level <= L_ERROR {
+		w = l.errOut
+	}
+	l.mu.Unlock()
+
+	_, err := fmt.Fprint(w, l.formatLine(e, color))
+	return err
+}
+
+// Settings used to format lines, copied under the lock so that they can be
+// changed while logging.
+type lineFormat struct {
+	format     int
+	style      int
+	colorStyle int
+	compact    bool
+	utc        bool
+	timeFormat string
+	messageKey string
+	timeKey    string
+	levelKey   string
+	tag        string
+	emfNS      string
+	emfMetrics map[string]bool
+	emfDims    []string
+	colors     [8]string
+	symbols    [8]string
+	now        func() time.Time
+}
+
+// Returns a copy of the settings used to format lines.
+func (l *Logger) lineFormat() lineFormat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return lineFormat{
+		format:     l.format,
+		style:      l.style,
+		colorStyle: l.colorStyle,
+		compact:    l.compact,
+		utc:        l.utc,
+		timeFormat: l.timeFormat,
+		messageKey: l.messageKey,
+		timeKey:    l.timeKey,
+		levelKey:   l.levelKey,
+		tag:        l.tag,
+		emfNS:      l.emfNS,
+		emfMetrics: l.emfMetrics,
+		emfDims:    l.emfDims,
+		colors:     l.colors,
+		symbols:    l.symbols,
+		now:        l.now,
+	}
+}
+
+// Formats a record the way it is printed on screen, with or without colors.
+func (l *Logger) formatLine(e record, colored bool) string {
+	return l.lineFormat().line(e, colored)
+}
+
+// Formats a record the way it is printed on screen, with or without colors.
+func (l lineFormat) line(e record, colored bool) string {
+	if l.format == FORMAT_JSON || l.format == FORMAT_EMF {
+		return l.json(e)
+	}
+
+	level, message := e.level, e.message
+	if colored && e.colored != "" {
+		message = colorSequence.ReplaceAllStringFunc(e.colored, l.applyColorStyle)
+	}
+
+	mColor, mHeader := levelStyle(level)
+	mReset := C_RESET
+
+	if level >= L_EMERGENCY && level <= L_DEBUG && l.colors[level] != "" {
+		mColor = l.colors[level]
+	}
+	if e.color != "" {
+		mColor = e.color
+	}
+	mColor = l.applyColorStyle(mColor)
+
+	if level >= L_EMERGENCY && level <= L_DEBUG {
+		switch l.style {
+		case STYLE_SYMBOL:
+			mHeader = " " + l.symbols[level] + " "
+		case STYLE_BOTH:
+			mHeader = " " + l.symbols[level] + mHeader
+		}
+	}
+
+	if l.compact {
+		mHeader = strings.TrimRight(mHeader, " ") + " "
+	}
+
+	if !colored {
+		mColor = ""
+		mReset = ""
+	}
+
+	if l.timeFormat == TIME_NONE {
+		return fmt.Sprintf("%s%s%s %s%s\n", mColor, mHeader, mReset, message, formatFields(e.fields))
+	}
+	return fmt.Sprintf("%s%s%s %s: %s%s\n", mColor, mHeader, mReset, l.timestamp(e.time, l.timeFormat), message, formatFields(e.fields))
+}
+
+// Marker ending the messages truncated by SetMaxMessageBytes.
+const truncatedMarker = "...[truncated]"
+
+// Shortens a message longer than n bytes to n bytes at most, marker
+// included, without splitting a character. The marker is left out if it
+// does not fit.
+func truncate(message string, n int) string {
+	marker := truncatedMarker
+	if n < len(marker) {
+		marker = ""
+	}
+	keep := n - len(marker)
+	for keep > 0 && !utf8.RuneStart(message[keep]) {
+		keep--
+	}
+	return message[:keep] + marker
+}
+
+// Returns the color and header of a level.
+func levelStyle(level int) (string, string) {
+	switch level {
+	case L_EMERGENCY:
+		return C_RED, M_EMERGENCY
+	case L_ALERT:
+		return C_RED, M_ALERT
+	case L_CRITICAL:
+		return C_YELLOW, M_CRITICAL
+	case L_ERROR:
+		return C_YELLOW, M_ERROR
+	case L_WARNING:
+		return C_MAGENTA, M_WARNING
+	case L_NOTICE:
+		return C_BLUE, M_NOTICE
+	case L_INFO:
+		return C_CYAN, M_INFO
+	case L_DEBUG:
+		return C_GREEN, M_DEBUG
+	}
+	return "", ""
+}
+
+// Returns a time formatted with the given layout, or as seconds since the
+// Unix epoch for TIME_UNIX. An empty layout stands for TIME_DEFAULT, and the
+// zero time for the current time.
+func (l lineFormat) timestamp(t time.Time, layout string) string {
+	if layout == "" {
+		layout = TIME_DEFAULT
+	}
+	if t.IsZero() {
+		t = l.now()
+	}
+	if l.utc {
+		t = t.UTC()
+	}
+	if layout == TIME_UNIX {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.Format(layout)
+}
+
+// Formats a record as a JSON object on a single line, with the CloudWatch
+// metadata first in the EMF format.
+// Fields named like one of the keys of the object are prefixed with
+// "fields.", so that they cannot be mistaken for them.
+func (l lineFormat) json(e record) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	if m := l.emfMetadata(e); m != nil {
+		writeJSON(&b, "_aws", m)
+		b.WriteByte(',')
+	}
+	if l.timeFormat != TIME_NONE {
+		writeJSON(&b, l.timeKey, l.timestamp(e.time, time.RFC3339))
+		b.WriteByte(',')
+	}
+	writeJSON(&b, l.levelKey, LevelName(e.level))
+	b.WriteByte(',')
+	if l.tag != "" {
+		writeJSON(&b, "tag", l.tag)
+		b.WriteByte(',')
+	}
+	writeJSON(&b, l.messageKey, e.message)
+	for _, k := range e.fields.keys() {
+		b.WriteByte(',')
+		if k == l.timeKey || k == l.levelKey || k == "tag" || k == l.messageKey ||
+			(k == "_aws" && l.format == FORMAT_EMF) {
+			writeJSON(&b, "fields."+k, e.fields[k])
+		} else {
+			writeJSON(&b, k, e.fields[k])
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Writes a JSON key/value pair. Values that cannot be marshaled are replaced
+// with a "<unserializable: reason>" string.
+func writeJSON(b *strings.Builder, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal("<unserializable: " + err.Error() + ">")
+	}
+	b.Write(k)
+	b.WriteByte(':')
+	b.Write(v)
+}
+
+// Formats fields as " key=value" pairs sorted by key. Values that are empty
+// or contain spaces, equal signs, quotes or non-printable characters are
+// quoted, and such characters are replaced with underscores in keys.
+func formatFields(f Fields) string {
+	var b strings.Builder
+	for _, k := range f.keys() {
+		v := fmt.Sprint(f[k])
+		if v == "" || strings.IndexFunc(v, unsafeFieldRune) >= 0 {
+			v = strconv.Quote(v)
+		}
+		key := strings.Map(func(r rune) rune {
+			if unsafeFieldRune(r) {
+				return '_'
+			}
+			return r
+		}, k)
+		b.WriteString(" " + key + "=" + v)
+	}
+	return b.String()
+}
+
+// Tells if a character is ambiguous in a key=value pair.
+func unsafeFieldRune(r rune) bool {
+	return r == ' ' || r == '=' || r == '"' || !unicode.IsPrint(r)
+}
+
+// Identifier of the structured-data element holding the fields of messages
+// sent to Syslog, using the enterprise number reserved for documentation.
+const sdID = "fields@32473"
+
+// Formats fields as an RFC 5424 structured-data element. Characters not
+// allowed in parameter names are replaced with underscores, and the
+// characters '"', '\' and ']' are escaped in values.
+func formatStructuredData(f Fields) string {
+	var b strings.Builder
+	b.WriteString("[" + sdID)
+	for _, k := range f.keys() {
+		b.WriteString(" " + sdName(k) + "=\"")
+		for _, r := range fmt.Sprint(f[k]) {
+			if r == '"' || r == '\\' || r == ']' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// Returns a valid RFC 5424 parameter name for a field key: at most 32
+// printable US-ASCII characters, without '=', ' ', ']' or '"'.
+func sdName(key string) string {
+	b := []byte(key)
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	for i, c := range b {
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// Tells if colors can be used when writing to w.
+// FORCE_COLOR always allows them. Otherwise, w must be a terminal, TERM must
+// not be "dumb" and NO_COLOR must not be set.
+func colorCapable(w io.Writer) bool {
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok && v != "0" {
+		return true
+	}
+
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if !isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()) {
+		return false
+	}
+
+	_, ok = os.LookupEnv("NO_COLOR")
+	return !ok
+}
+
+// Matches the color sequences of colored messages, to adapt them to the
+// color style.
+var colorSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Adapts a color to the color style.
+// The background of the color is turned into a foreground color for
+// COLOR_FG, or kept alone for COLOR_BG. The parameters of 256 and 24-bit
+// colors, as given by Color256 and ColorRGB, are left alone.
+func (l lineFormat) applyColorStyle(c string) string {
+	if l.colorStyle == COLOR_BOTH || !strings.HasPrefix(c, "\x1b[") || !strings.HasSuffix(c, "m") {
+		return c
+	}
+
+	params := strings.Split(c[2:len(c)-1], ";")
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		if p == "38" || p == "48" {
+			// Skip the palette index or the red, green and blue values.
+			if i+1 < len(params) && params[i+1] == "5" {
+				i += 2
+			} else if i+1 < len(params) && params[i+1] == "2" {
+				i += 4
+			}
+			continue
+		}
+		if len(p) == 2 && p[0] == '4' {
+			if l.colorStyle == COLOR_FG {
+				return "\x1b[3" + p[1:] + "m"
+			}
+			return "\x1b[" + p + "m"
+		}
+	}
+	return c
+}
+
+// Sends a record to Syslog, with its fields appended to the message, or
+// writes it to the log file when opened with OpenFile.
+// If the logging system is not open yet and buffering is enabled, the
+// record is kept until it is started. Otherwise, ErrNotOpened is returned.
+func (l *Logger) sendToSyslog(e record) error {
+	// Keep the writer from being closed by SetTag or Close while in use.
+	l.sMu.RLock()
+	defer l.sMu.RUnlock()
+
+	l.mu.Lock()
+	s, file := l.s, l.file
+	l.mu.Unlock()
+
+	if file != nil {
+		_, err := io.WriteString(file, l.formatLine(e, false))
+		return err
+	}
+
+	if s == nil {
+		l.mu.Lock()
+		if l.bufferSize > 0 {
+			if len(l.buffered) >= l.bufferSize {
+				l.buffered = l.buffered[1:]
+			}
+			l.buffered = append(l.buffered, e)
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+		return ErrNotOpened
+	}
+
+	if w, ok := s.(*journalWriter); ok {
+		return w.send(e)
+	}
+
+	var sd string
+	if len(e.fields) > 0 {
+		sd = formatStructuredData(e.fields)
+	}
+	if w, ok := s.(*rfc5424Writer); ok {
+		return w.write(e.level, e.time, e.component, sd, e.message)
+	}
+
+	message := e.message
+	if sd != "" {
+		message = sd + " " + message
+	}
+	if w, ok := s.(componentWriter); ok {
+		return w.writeComponent(e.level, e.component, message)
+	}
+	return writeSyslog(s, e.level, message)
+}
+
+// A connection to Syslog, or to the Event Log on Windows.
+type syslogWriter interface {
+	Emerg(m string) error
+	Alert(m string) error
+	Crit(m string) error
+	Err(m string) error
+	Warning(m string) error
+	Notice(m string) error
+	Info(m string) error
+	Debug(m string) error
+	Close() error
+}
+
+// A Syslog writer appending the component of messages to its tag.
+type componentWriter interface {
+	writeComponent(level int, component, message string) error
+}
+
+// Sends a message to a Syslog writer at the given level.
+func writeSyslog(w syslogWriter, level int, message string) error {
+	switch level {
+	case L_EMERGENCY:
+		return w.Emerg(message)
+	case L_ALERT:
+		return w.Alert(message)
+	case L_CRITICAL:
+		return w.Crit(message)
+	case L_ERROR:
+		return w.Err(message)
+	case L_WARNING:
+		return w.Warning(message)
+	case L_NOTICE:
+		return w.Notice(message)
+	case L_INFO:
+		return w.Info(message)
+	default:
+		return w.Debug(message)
+	}
+}
+
+// Joins the errors that are not nil. A single error is returned as is.
+func joinErrors(errs ...error) error {
+	var last error
+	n := 0
+	for _, err := range errs {
+		if err != nil {
+			last = err
+			n++
+		}
+	}
+	if n <= 1 {
+		return last
+	}
+	return errors.Join(errs...)
+}
+
+// Keeps up to n messages in memory until the logging system is started,
+// see BufferUntilOpen.
+func (l *Logger) bufferUntilOpen(n int) {
+	l.mu.Lock()
+	l.bufferSize = n
+	if n <= 0 {
+		l.buffered = nil
+	} else if len(l.buffered) > n {
+		l.buffered = l.buffered[len(l.buffered)-n:]
+	}
+	l.mu.Unlock()
+}
+
+// Sends the buffered messages to Syslog.
+// Returns the first error encountered, if any.
+func (l *Logger) flushBuffered() error {
+	l.mu.Lock()
+	pending := l.buffered
+	l.buffered = nil
+	l.bufferSize = 0
+	l.mu.Unlock()
+
+	var err error
+	for _, e := range pending {
+		if e2 := l.sendToSyslog(e); e2 != nil && err == nil {
+			err = e2
+		}
+	}
+	return err
+}
@@ -0,0 +1,385 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Logs from several goroutines while changing the settings, to be run with
+// -race.
+func TestConcurrentSettings(t *testing.T) {
+	l, err := NewFile(filepath.Join(t.TempDir(), "test.log"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetMirror(true)
+	l.SetScreenOutput(io.Discard)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e := l.WithFields(Fields{"k": "v"})
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.Err("message")
+				l.Notice("message")
+				e.Warning("message")
+				l.FprintLevel(io.Discard, L_INFO, "message")
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		l.SetLevelColor(L_ERROR, Color256(uint8(i)))
+		l.ResetColors()
+		l.SetColorStyle(i % 3)
+		l.SetStyle(i % 3)
+		l.SetCompact(i%2 == 0)
+		l.SetSymbols(map[int]string{L_ERROR: "x"})
+		l.SetFormat(i % 2)
+		l.SetTimeFormat(TIME_UNIX)
+		l.SetTimeUTC(i%2 == 0)
+		l.SetTimeSource(time.Now)
+		l.SetCaller(i%2 == 0)
+		l.SetCallerSkip(0)
+		l.SetMaxMessageBytes(i % 20)
+		l.SetHook(func(int, string) {})
+		l.SetErrorStack(i%2 == 0)
+		l.SetDebug(i%2 == 0)
+		l.SetVerbose(i%2 == 0)
+		l.SetLevel(L_DEBUG)
+		l.SetOutput(io.Discard)
+		l.SetErrorOutput(io.Discard)
+		l.SetExitCode(2)
+		l.SetVersion("1.0", "abc")
+		l.Apply(l.Snapshot())
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// Logs from several goroutines in asynchronous mode while flushing.
+func TestConcurrentAsync(t *testing.T) {
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(io.Discard)
+	l.SetAsync(8)
+	defer l.stopAsync()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Notice("message")
+				if j%10 == 0 {
+					l.Flush()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestVersionFields(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetVersion("1.2.0", "abc123")
+
+	fields := Fields{"k": "v"}
+	l.WithFields(fields).Err("with fields")
+	l.WithFields(Fields{"version": "own"}).Err("own version")
+	l.Err("plain")
+
+	out := l.DryRunOutput()
+	for i, want := range []string{
+		`"commit":"abc123","k":"v","version":"1.2.0"}`,
+		`"commit":"abc123","version":"own"}`,
+		`"commit":"abc123","version":"1.2.0"}`,
+	} {
+		if !strings.HasSuffix(out[i], want) {
+			t.Errorf("line %d = %s, want suffix %s", i, out[i], want)
+		}
+	}
+	if len(fields) != 1 {
+		t.Errorf("caller fields changed: %v", fields)
+	}
+	if sd := formatStructuredData(withBuild(nil, "1.2.0", "")); sd != `[fields@32473 version="1.2.0"]` {
+		t.Errorf("structured data = %s", sd)
+	}
+}
+
+func TestSampleBurstBounded(t *testing.T) {
+	now := time.Now()
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetTimeSource(func() time.Time { return now })
+	l.SetSampleBurst(L_ERROR, 1, 0)
+
+	for i := 0; i < sampleKeys+10; i++ {
+		l.Err(strconv.Itoa(i))
+	}
+	if n := len(l.bursts[L_ERROR].seen); n > sampleKeys {
+		t.Fatalf("sampling keeps %d messages, want at most %d", n, sampleKeys)
+	}
+
+	l.Err("repeated")
+	l.Err("repeated")
+	now = now.Add(sampleWindow)
+	l.Err("repeated")
+	if n := strings.Count(strings.Join(l.DryRunOutput(), "\n"), "repeated"); n != 2 {
+		t.Fatalf("got %d repeated messages, want 2", n)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tt := range []struct {
+		message string
+		n       int
+		want    string
+	}{
+		{"hello world, this is long", 19, "hello...[truncated]"},
+		{"hello world", 5, "hello"},
+		{"héllo", 2, "h"},
+		{"héllo world, this is long", 16, "h...[truncated]"},
+		{"hello", 0, ""},
+	} {
+		got := truncate(tt.message, tt.n)
+		if got != tt.want || len(got) > tt.n {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.message, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshotApply(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "0")
+	l := newLogger()
+	l.SetScreenOutput(io.Discard)
+	l.AddWriter(io.Discard, L_ERROR)
+	l.SetLevelColor(L_ERROR, C_CYAN)
+	l.SetCaller(true)
+	l.SetMaxMessageBytes(100)
+	l.SetRateLimit(10)
+	l.SetDedup(true)
+
+	c := l.Snapshot()
+	c.Color = true
+	m := newLogger()
+	m.Apply(c)
+
+	got := m.Snapshot()
+	if got.Color {
+		t.Error("colors enabled on an output that is not a terminal")
+	}
+	if got.Out != io.Discard || len(got.Sinks) != 1 || got.Colors[L_ERROR] != C_CYAN ||
+		!got.Caller || got.MaxBytes != 100 || got.RateLimit != 10 || !got.Dedup {
+		t.Errorf("settings not applied: %+v", got)
+	}
+}
+
+func TestTimeNone(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetTimeSource(func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) })
+	l.SetTimeUTC(true)
+
+	l.Err("default")
+	l.SetTimeFormat(TIME_NONE)
+	l.Err("none")
+	l.SetFormat(FORMAT_JSON)
+	l.Err("json")
+
+	out := l.DryRunOutput()
+	if !strings.Contains(out[0], "2026-01-02 03:04:05: default") {
+		t.Errorf("got %q", out[0])
+	}
+	if strings.Contains(out[1], "2026") || strings.Contains(out[1], "none:") || !strings.HasSuffix(out[1], " none") {
+		t.Errorf("got %q", out[1])
+	}
+	if out[2] != `{"level":"ERROR","msg":"json"}` {
+		t.Errorf("got %q", out[2])
+	}
+}
+
+func TestSampling(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetSampling(L_WARNING, 2, 5)
+
+	for i := 0; i < 22; i++ {
+		l.Warning("flood")
+	}
+	// The first 2, then the 7th, 12th, 17th and 22nd.
+	if n := len(l.DryRunOutput()); n != 6 {
+		t.Fatalf("got %d messages, want 6", n)
+	}
+
+	l.SetSampling(L_WARNING, 0, 1)
+	l.Warning("flood")
+	if n := len(l.DryRunOutput()); n != 7 {
+		t.Fatalf("sampling not disabled, got %d messages", n)
+	}
+}
+
+func TestJSONReservedFields(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+
+	l.WithFields(Fields{"level": "DEBUG", "msg": "spoofed", "tag": "other", "time": "now", "k": "v"}).Err("real")
+
+	want := `{"level":"ERROR","msg":"real","k":"v","fields.level":"DEBUG","fields.msg":"spoofed","fields.tag":"other","fields.time":"now"}`
+	if out := l.DryRunOutput(); out[0] != want {
+		t.Fatalf("got %s, want %s", out[0], want)
+	}
+}
+
+func TestFormatFields(t *testing.T) {
+	got := formatFields(Fields{"a b": "c", "k": "v", "e": "", "n": "x\ny", "t": "a\tb"})
+	want := ` a_b=c e="" k=v n="x\ny" t="a\tb"`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// A Syslog writer failing to send errors and warnings.
+type failingSyslog struct{ syslogWriter }
+
+func (failingSyslog) Err(string) error     { return errors.New("unreachable") }
+func (failingSyslog) Warning(string) error { return errors.New("unreachable") }
+func (failingSyslog) Close() error         { return nil }
+
+func TestStartReportsReplayFailure(t *testing.T) {
+	var errOut bytes.Buffer
+	l := newLogger()
+	l.SetErrorOutput(&errOut)
+	l.SetDaemonStderrLevel(L_WARNING)
+	l.bufferUntilOpen(1)
+	l.Err("early")
+
+	l.s = failingSyslog{}
+	l.start()
+	defer l.Close()
+	if !strings.Contains(errOut.String(), "unable to send the messages logged before opening: unreachable") {
+		t.Fatalf("got %q", errOut.String())
+	}
+}
+
+// A value failing to marshal to JSON.
+type badJSON struct{}
+
+func (badJSON) MarshalJSON() ([]byte, error) { return nil, errors.New("broken") }
+
+func TestJSONUnserializable(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+
+	l.WithFields(Fields{"bad": badJSON{}, "ch": make(chan int), "k": "v"}).Err("kept")
+
+	var got map[string]string
+	if err := json.Unmarshal([]byte(l.DryRunOutput()[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		"msg": "kept",
+		"bad": "<unserializable: json: error calling MarshalJSON for type *logger.badJSON: broken>",
+		"ch":  "<unserializable: json: unsupported type: chan int>",
+		"k":   "v",
+	} {
+		if got[k] != want {
+			t.Errorf("%s = %q, want %q", k, got[k], want)
+		}
+	}
+}
+
+func TestJSONEscaping(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+
+	l.WithFields(Fields{"k\"ey": "a\"b\nc\td"}).Err("say \"hi\"\n\tbye")
+
+	want := `{"level":"ERROR","msg":"say \"hi\"\n\tbye","k\"ey":"a\"b\nc\td"}`
+	if out := l.DryRunOutput(); out[0] != want {
+		t.Fatalf("got %s, want %s", out[0], want)
+	}
+}
+
+func TestWithError(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+
+	l.WithFields(Fields{"k": "v"}).WithError(errors.New("boom")).Err("failed")
+	l.WithError(nil).Err("no error")
+
+	out := l.DryRunOutput()
+	if want := `{"level":"ERROR","msg":"failed","error":"boom","errorType":"*errors.errorString","k":"v"}`; out[0] != want {
+		t.Errorf("got %s, want %s", out[0], want)
+	}
+	if want := `{"level":"ERROR","msg":"no error"}`; out[1] != want {
+		t.Errorf("got %s, want %s", out[1], want)
+	}
+}
+
+func TestJSONKeys(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeSource(func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) })
+	l.SetTimeUTC(true)
+	l.SetMessageKey("@message")
+	l.SetTimeKey("@timestamp")
+	l.SetLevelKey("severity")
+
+	l.WithFields(Fields{"msg": "kept", "severity": "spoofed"}).Err("hi")
+
+	want := `{"@timestamp":"2026-01-02T03:04:05Z","severity":"ERROR","@message":"hi","msg":"kept","fields.severity":"spoofed"}`
+	if out := l.DryRunOutput(); out[0] != want {
+		t.Fatalf("got %s, want %s", out[0], want)
+	}
+}
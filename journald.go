@@ -0,0 +1,122 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Network given to dial to connect to journald.
+const journaldNetwork = "journald"
+
+// Path of the socket of journald.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// Sends messages to journald with the native protocol, so that the priority,
+// identifier, code location and fields are stored as journal fields.
+type journalWriter struct {
+	mu   sync.Mutex
+	tag  string
+	conn net.Conn
+}
+
+// Connects to the socket of journald.
+func dialJournald(tag string) (*journalWriter, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &journalWriter{tag: tag, conn: conn}, nil
+}
+
+// Sends a record with its fields and the location of its caller, if known.
+func (w *journalWriter) send(e record) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(e.level))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", componentTag(w.tag, e.component))
+	if e.frame.File != "" {
+		writeJournalField(&b, "CODE_FILE", e.frame.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(e.frame.Line))
+		writeJournalField(&b, "CODE_FUNC", filepath.Base(e.frame.Function))
+	}
+	for _, k := range e.fields.keys() {
+		writeJournalField(&b, journalFieldName(k), fmt.Sprint(e.fields[k]))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.conn.Write(b.Bytes())
+	return err
+}
+
+// Sends a message without fields at the given level.
+func (w *journalWriter) message(level int, m string) error {
+	return w.send(record{level: level, message: m})
+}
+
+// Closes the connection.
+func (w *journalWriter) Close() error {
+	return w.conn.Close()
+}
+
+func (w *journalWriter) Emerg(m string) error   { return w.message(L_EMERGENCY, m) }
+func (w *journalWriter) Alert(m string) error   { return w.message(L_ALERT, m) }
+func (w *journalWriter) Crit(m string) error    { return w.message(L_CRITICAL, m) }
+func (w *journalWriter) Err(m string) error     { return w.message(L_ERROR, m) }
+func (w *journalWriter) Warning(m string) error { return w.message(L_WARNING, m) }
+func (w *journalWriter) Notice(m string) error  { return w.message(L_NOTICE, m) }
+func (w *journalWriter) Info(m string) error    { return w.message(L_INFO, m) }
+func (w *journalWriter) Debug(m string) error   { return w.message(L_DEBUG, m) }
+
+// Writes a journal field. Values containing a newline are written with
+// their length, as the protocol requires.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(name + "=" + value + "\n")
+		return
+	}
+	b.WriteString(name + "\n")
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+// Returns a valid journal field name for a field key: uppercase letters,
+// digits and underscores, not starting with an underscore or a digit.
+func journalFieldName(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || b[0] == '_' || (b[0] >= '0' && b[0] <= '9') {
+		return "F" + string(b)
+	}
+	return string(b)
+}
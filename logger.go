@@ -24,13 +24,16 @@
 package logger
 
 import (
-	"fmt"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
-	"log/syslog"
-
-	"github.com/mattn/go-isatty"
 )
 
 const (
@@ -54,13 +57,55 @@ const (
 	M_NOTICE     = " NOTICE    "
 	M_INFO       = " INFO      "
 	M_DEBUG      = " DEBUG     "
+
+	// Screen styles
+	STYLE_TEXT   = 0
+	STYLE_SYMBOL = 1
+	STYLE_BOTH   = 2
+
+	// Screen formats
+	FORMAT_TEXT  = 0
+	FORMAT_JSON  = 1
+	FORMAT_EMF   = 2
+
+	// Timestamp layouts
+	TIME_DEFAULT = "2006-01-02 15:04:05"
+	TIME_UNIX    = "unix"
+	TIME_NONE    = "none"
+
+	// Rotated log files kept by OpenFile
+	FILE_BACKUPS = 5
+
+	// Hook calls queued for the hook workers
+	HOOK_QUEUE   = 1024
+
+	// Color styles
+	COLOR_BOTH   = 0
+	COLOR_FG     = 1
+	COLOR_BG     = 2
+
+	// Behaviors of the asynchronous mode when its buffer is full
+	ASYNC_BLOCK  = 0
+	ASYNC_DROP   = 1
+
+	// Caller styles
+	CALLER_BOTH  = 0
+	CALLER_FILE  = 1
+	CALLER_FUNC  = 2
 )
 
 var (
-	s            *syslog.Writer
-	debug        = false
-	verbose      = false
-	color        = true
+	// Default logger used by the package-level functions
+	std          = newLogger()
+
+	// Level names, indexed by level
+	levelNames   = [8]string{"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG"}
+
+	// Other names accepted by ParseLevel
+	levelAliases = map[string]int{"EMERG": L_EMERGENCY, "CRIT": L_CRITICAL, "ERR": L_ERROR, "WARN": L_WARNING}
+
+	// Default symbols used by the symbol styles, indexed by level
+	defaultSymbols = [8]string{"‼", "!", "✗", "✗", "⚠", "•", "ℹ", "›"}
 
 	// Colors
 	C_BLUE       = string([]byte{27, 91, 57, 55, 59, 52, 52, 109})
@@ -74,84 +119,660 @@ var (
 
 )
 
+// Returned when sending a message to Syslog before Open or after Close.
+var ErrNotOpened = errors.New("logger: not opened")
+
+// Returned when logging at a level that does not exist.
+var ErrInvalidLevel = errors.New("logger: invalid level")
+
+// Per-message options for LogOpt.
+type LogOptions struct {
+	Level      int    // Logging level (L_*)
+	Message    string // Message to log
+	Fields     Fields // Structured fields
+	ScreenOnly bool   // Only print the message on screen
+	SyslogOnly bool   // Only send the message to Syslog
+	Color      string // Color of the header on screen, overrides the level color
+	Sync       bool   // Write the message before returning, even in asynchronous mode
+}
+
+// Logging configuration, as returned by Snapshot and used by Apply.
+type Config struct {
+	Debug     bool      // Debug mode, see SetDebug
+	Mirror    bool      // Mirror mode, see SetMirror
+	Verbose   bool      // Verbose mode, see SetVerbose
+	Default   int       // Level logged when not verbose, see SetDefaultLevel
+	Level     int       // Least severe level logged, see SetLevel
+	Color     bool      // Colors on screen
+	Style     int       // Screen style (STYLE_*)
+	Compact   bool      // Level names without padding, see SetCompact
+	Format    int       // Screen format (FORMAT_*)
+	Time      string    // Timestamp layout, see SetTimeFormat
+	UTC       bool      // Timestamps in UTC, see SetTimeUTC
+	ColorMode int       // Color style (COLOR_*)
+	Symbols   [8]string // Symbols used by the symbol styles, indexed by level
+	Lifecycle bool      // Start and stop messages, see SetLifecycleLogs
+	Stderr    int       // Level echoed to stderr, see SetDaemonStderrLevel
+	Screen    int       // Least severe level on screen, see SetScreenLevel
+	Syslog    int       // Least severe level sent to Syslog, see SetSyslogLevel
+	Out       io.Writer // Screen output, see SetOutput; nil keeps the current one
+	ErrOut    io.Writer // Error output, see SetErrorOutput; nil keeps the current one
+	Sinks     []Sink    // Sinks, see AddSink and AddWriter
+	Colors    [8]string // Level colors, see SetLevelColor; empty for the default
+	Caller    bool      // Caller in front of messages, see SetCaller
+	Skip      int       // Frames skipped to find the caller, see SetCallerSkip
+	CallStyle int       // Caller style (CALLER_*), see SetCallerStyle
+	MaxBytes  int       // Size limit of messages, see SetMaxMessageBytes
+	RateLimit int       // Messages per second, see SetRateLimit
+	RateBurst int       // Messages at once, see SetRateLimitBurst
+	Dedup     bool      // Repeated messages collapsed, see SetDedup
+	NoColor   bool      // Colors disabled, see DisableColor
+	MsgKey    string    // Key of the message in JSON, see SetMessageKey
+	TimeKey   string    // Key of the time in JSON, see SetTimeKey
+	LevelKey  string    // Key of the level in JSON, see SetLevelKey
+}
+
+// Returns the name of a level, such as "ERROR" for L_ERROR, or "UNKNOWN"
+// if the level does not exist.
+func LevelName(level int) string {
+	if !IsValidLevel(level) {
+		return "UNKNOWN"
+	}
+	return levelNames[level]
+}
+
+// Tells if a level exists, from L_EMERGENCY to L_DEBUG.
+func IsValidLevel(level int) bool {
+	return level >= L_EMERGENCY && level <= L_DEBUG
+}
+
+// Returns the level matching a name, such as L_ERROR for "error".
+// The name is case-insensitive and surrounding whitespace is ignored. The
+// Syslog short names ("emerg", "crit", "err") and "warn" are accepted too.
+// Returns an error if no level has that name.
+func ParseLevel(name string) (int, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	for level, n := range levelNames {
+		if n == key {
+			return level, nil
+		}
+	}
+	if level, ok := levelAliases[key]; ok {
+		return level, nil
+	}
+	return -1, fmt.Errorf("logger: unknown level %q", name)
+}
+
 // Starts the logging system.
 // Takes a tag parameter to specify the name of the program.
+// Colors are disabled if the output is not a terminal, if TERM is "dumb" or
+// if NO_COLOR is set. FORCE_COLOR, set to anything but 0, takes precedence
+// and keeps them enabled.
+// On Windows, messages are sent to the Event Log instead of Syslog, with the
+// tag as source.
 // Returns an error if unable to start logging.
 func Open(tag string) error {
-	var err error
+	return std.open("", "", defaultPriority, tag)
+}
 
-	if tag != "" {
-		s, err =  syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, tag)
-		if err != nil {
-			return err
-		}
-	} else {
-		return errors.New("logger: tag cannot be empty")
-	}
+// Starts the logging system with the given Syslog facility and severity,
+// such as syslog.LOG_LOCAL0|syslog.LOG_INFO, instead of the default
+// syslog.LOG_DAEMON|syslog.LOG_WARNING.
+// Takes a tag parameter to specify the name of the program.
+// Returns an error if unable to start logging.
+func OpenWithPriority(priority Priority, tag string) error {
+	return std.open("", "", priority, tag)
+}
+
+// Starts the logging system, sending messages to a remote Syslog server.
+// Takes the network ("tcp" or "udp") and address of the server, and a tag
+// parameter to specify the name of the program, sent as the app-name.
+// Messages are sent in the RFC 5424 format, with their fields as structured
+// data, and framed with octet counting over TCP. The connection is opened
+// again if it breaks.
+// Returns an error if unable to connect to the server.
+func OpenRemote(network, raddr, tag string) error {
+	return std.open(network, raddr, defaultPriority, tag)
+}
+
+// Starts the logging system, sending messages to the systemd journal with
+// its native protocol instead of Syslog.
+// Takes a tag parameter to specify the name of the program, sent as the
+// SYSLOG_IDENTIFIER field. Fields are sent as journal fields, with their keys
+// in uppercase, along with the file, line and function of the caller.
+// Returns an error if unable to connect to journald.
+func OpenJournald(tag string) error {
+	return std.open(journaldNetwork, "", defaultPriority, tag)
+}
+
+// Sets the hostname sent to remote Syslog servers. Must be called before
+// OpenRemote.
+// The name of the machine by default.
+func SetHostname(name string) {
+	std.SetHostname(name)
+}
+
+// Starts the logging system, writing to a file instead of Syslog.
+// Messages are formatted as on screen, without colors. When the file would
+// grow beyond maxBytes, it is renamed to path.1, previous backups are
+// shifted (path.1 to path.2, and so on, keeping FILE_BACKUPS of them) and a
+// new file is started. A zero or negative maxBytes disables rotation.
+// Returns an error if unable to open the file.
+func OpenFile(path string, maxBytes int64) error {
+	return std.openFile(path, maxBytes)
+}
 
-	if (os.Getenv("TERM") == "dumb" || (!isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()))) {
-		color = false
+// Starts the logging system, writing to a file as OpenFile does but with
+// the given rotation settings. Backups older than MaxAgeDays are removed on
+// rotation, and Compress gzips them as path.1.gz, path.2.gz, and so on.
+// Returns an error if unable to open the file.
+func OpenFileWithOptions(path string, opts FileOptions) error {
+	return std.openFileWithOptions(path, opts)
+}
+
+// Returns the logger used by the package-level functions, to pass it to code
+// expecting a *Logger. Settings changed on it apply to the package-level
+// functions too.
+// Returns nil if the logging system is not started.
+func Default() *Logger {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if std.s == nil && std.file == nil {
+		return nil
 	}
+	return std
+}
 
-	return nil
+// Changes the tag of the messages sent to Syslog, reconnecting with the
+// same network, address and priority. Other settings are kept.
+// Returns ErrNotOpened if not logging to Syslog, or an error if unable to
+// reconnect, in which case the current connection is kept.
+func SetTag(tag string) error {
+	return std.SetTag(tag)
 }
 
 // Stops the logging system.
-// Should be called at the end of the program.
+// Should be called at the end of the program. Calling it again, or without
+// a successful Open, has no effect, so it can safely be deferred before
+// checking the result of Open. Repeated calls return the result of the
+// first call. Settings are kept for a subsequent Open.
 // Returns an error if unable to stop logging.
 func Close() error {
-	err := s.Close()
-	return err
+	return std.Close()
 }
 
 // Prints a message to the screen.
 // Will check if color can be used or not.
+// Write errors are ignored, see FprintLevel to get them. Unknown levels are
+// printed as Info-level messages.
 func PrintToScreen(level int, message string) {
-	var (
-		mColor  string
-		mReset  string
-		mHeader string
-	)
-
-	switch level {
-	case L_EMERGENCY:
-		mColor  = C_RED
-		mHeader = M_EMERGENCY
-	case L_ALERT:
-		mColor  = C_RED
-		mHeader = M_ALERT
-	case L_CRITICAL:
-		mColor  = C_YELLOW
-		mHeader = M_CRITICAL
-	case L_ERROR:
-		mColor  = C_YELLOW
-		mHeader = M_ERROR
-	case L_WARNING:
-		mColor  = C_MAGENTA
-		mHeader = M_WARNING
-	case L_NOTICE:
-		mColor  = C_BLUE
-		mHeader = M_NOTICE
-	case L_INFO:
-		mColor  = C_CYAN
-		mHeader = M_INFO
-	case L_DEBUG:
-		mColor  = C_GREEN
-		mHeader = M_DEBUG
+	if !IsValidLevel(level) {
+		level = L_INFO
 	}
-	mReset = C_RESET
+	std.printToScreen(record{level: level, message: message})
+}
 
-	if(!color) {
-		mColor = ""
-		mReset = ""
-	}
+// Writes a message to w formatted the same way as on screen, following the
+// current format and style settings, such as to a response buffer or a
+// network connection. Colors are used only if enabled and w is a terminal.
+// Returns ErrInvalidLevel for unknown levels, or an error if unable to write
+// it.
+func FprintLevel(w io.Writer, level int, message string) error {
+	return std.FprintLevel(w, level, message)
+}
+
+// Sets the writer messages are printed to instead of the screen, such as a
+// file or a buffer. Colors are enabled if the outputs are terminals, unless
+// disabled with DisableColor, and disabled otherwise.
+// Error-level and more severe messages go to the error output instead.
+// Stderr by default, so that logs do not mix with the data a program writes
+// to stdout.
+func SetOutput(w io.Writer) {
+	std.SetOutput(w)
+}
+
+// Sets the writer all messages are printed to, both the output and the error
+// output, such as os.Stdout. Colors are enabled if it is a terminal, unless
+// disabled with DisableColor, and disabled otherwise.
+// Stderr by default.
+func SetScreenOutput(w io.Writer) {
+	std.SetScreenOutput(w)
+}
 
-	fmt.Printf("%s%s%s %s: %s\n", mColor, mHeader, mReset, time.Now().Format("2006-01-02 15:04:05"), message)
+// Sets the writer Error-level and more severe messages are printed to.
+// Colors are enabled if the outputs are terminals, unless disabled with
+// DisableColor, and disabled otherwise.
+// Stderr by default.
+func SetErrorOutput(w io.Writer) {
+	std.SetErrorOutput(w)
 }
 
 // Disable colors in messages printed to screen.
 func DisableColor() {
-	color = false
+	std.DisableColor()
+}
+
+// Enable colors in messages printed to screen.
+// Colors stay disabled if the outputs are not terminals, following the same
+// rules as Open, so FORCE_COLOR can be used to force them.
+func EnableColor() {
+	std.EnableColor()
+}
+
+// Sets how colors are rendered on screen: COLOR_BOTH uses the foreground
+// and background colors, COLOR_FG only a colored foreground and COLOR_BG only
+// a colored background.
+// COLOR_BOTH by default.
+func SetColorStyle(cs int) {
+	std.SetColorStyle(cs)
+}
+
+// Writes messages at the given level or more severe to the error output
+// (stderr by default) when they are not printed on screen, so that they are
+// captured by the service manager even if Syslog is misconfigured.
+// A negative level disables it.
+// Off (-1) by default.
+func SetDaemonStderrLevel(level int) {
+	std.SetDaemonStderrLevel(level)
+}
+
+// Sets the logging to dry run mode using the supplied boolean.
+// When set to true, messages go through the whole logging process but are
+// captured instead of being printed or sent to Syslog. The captured lines
+// can be retrieved with DryRunOutput. Turning it on clears them.
+// Off (false) by default.
+func SetDryRun(b bool) {
+	std.SetDryRun(b)
+}
+
+// Returns the lines captured in dry run mode, without colors.
+func DryRunOutput() []string {
+	return std.DryRunOutput()
+}
+
+// Sets the least severe level logged when verbose is off. Emergency
+// messages are always logged.
+// L_NOTICE by default.
+func SetDefaultLevel(level int) {
+	std.SetDefaultLevel(level)
+}
+
+// Sets the least severe level logged, regardless of the verbose setting.
+// For instance, L_WARNING drops Notice, Info and Debug messages.
+// Emergency messages are always logged. Replaces the levels set with
+// SetScreenLevel and SetSyslogLevel.
+// L_DEBUG by default.
+func SetLevel(level int) {
+	std.SetLevel(level)
+}
+
+// Sets the least severe level printed on screen, independently of Syslog.
+// Messages still go to the screen only when the debug or mirror mode
+// requires it. A negative level follows SetLevel.
+// Follows SetLevel by default.
+func SetScreenLevel(level int) {
+	std.SetScreenLevel(level)
+}
+
+// Sets the least severe level sent to Syslog, independently of the screen.
+// A negative level follows SetLevel.
+// Follows SetLevel by default.
+func SetSyslogLevel(level int) {
+	std.SetSyslogLevel(level)
+}
+
+// Logs a Notice when the logging system starts and stops, with the pid and
+// hostname at start and the uptime at stop.
+// Off (false) by default.
+func SetLifecycleLogs(b bool) {
+	std.SetLifecycleLogs(b)
+}
+
+// Generates a correlation ID when the logging system starts, added as the
+// "corr_id" field of every message unless set by the message or its
+// context, to correlate messages across services without full tracing. The
+// ID is kept until disabled, so that it stays the same for the process.
+// Off (false) by default.
+func SetProcessCorrelationID(b bool) {
+	std.SetProcessCorrelationID(b)
+}
+
+// Sets the version and commit of the program, added as the "version" and
+// "commit" fields of every message unless empty or set by the message, and
+// shown in the start message logged when SetLifecycleLogs is on.
+func SetVersion(v string, c string) {
+	std.SetVersion(v, c)
+}
+
+// Sets the format of messages printed to screen or written by OpenFile.
+// FORMAT_TEXT prints a colored header followed by the message, FORMAT_JSON
+// one JSON object per line with the time (RFC 3339), level, Syslog tag if
+// any, message and fields, without colors. The keys of the time, level and
+// message are set by SetTimeKey, SetLevelKey and SetMessageKey. FORMAT_EMF
+// adds to the JSON objects the metadata of the CloudWatch embedded metric
+// format, so that CloudWatch extracts metrics from the fields, see
+// SetEMFNamespace.
+// FORMAT_TEXT by default.
+func SetFormat(f int) {
+	std.SetFormat(f)
+}
+
+// Sets the CloudWatch namespace of the metrics in the FORMAT_EMF format.
+// The Syslog tag by default, or "aws-embedded-metrics" without one, or if
+// empty.
+func SetEMFNamespace(ns string) {
+	std.SetEMFNamespace(ns)
+}
+
+// Sets the fields logged as metrics in the FORMAT_EMF format, among those
+// with a numeric value. Without keys, all the numeric fields are, except the
+// dimensions.
+// All the numeric fields by default.
+func SetEMFMetrics(keys ...string) {
+	std.SetEMFMetrics(keys...)
+}
+
+// Sets the fields logged as dimensions of the metrics in the FORMAT_EMF
+// format, in order. Messages missing some of them use the others.
+// None by default.
+func SetEMFDimensions(keys ...string) {
+	std.SetEMFDimensions(keys...)
+}
+
+// Sets the layout of timestamps printed on screen, as accepted by
+// time.Format, TIME_UNIX for seconds since the Unix epoch, or TIME_NONE to
+// omit them, for instance under systemd or Docker which add their own. The
+// JSON format always uses RFC 3339, and omits the time with TIME_NONE.
+// TIME_DEFAULT by default, or if empty.
+func SetTimeFormat(layout string) {
+	std.SetTimeFormat(layout)
+}
+
+// Sets the key of the message in the JSON format, such as "message" or
+// "@message" as expected by some backends. Fields with the same name are
+// logged with a "fields." prefix.
+// "msg" by default, or if empty.
+func SetMessageKey(key string) {
+	std.SetMessageKey(key)
+}
+
+// Sets the key of the time in the JSON format, such as "@timestamp".
+// Fields with the same name are logged with a "fields." prefix.
+// "time" by default, or if empty.
+func SetTimeKey(key string) {
+	std.SetTimeKey(key)
+}
+
+// Sets the key of the level in the JSON format, such as "severity".
+// Fields with the same name are logged with a "fields." prefix.
+// "level" by default, or if empty.
+func SetLevelKey(key string) {
+	std.SetLevelKey(key)
+}
+
+// Prints timestamps in UTC instead of local time using the supplied
+// boolean.
+// Off (false) by default.
+func SetTimeUTC(b bool) {
+	std.SetTimeUTC(b)
+}
+
+// Sets the function giving the current time used for timestamps, for
+// instance to freeze time in tests. Nil restores the default.
+// time.Now by default.
+func SetTimeSource(now func() time.Time) {
+	std.SetTimeSource(now)
+}
+
+// Truncates messages longer than n bytes, on screen and in Syslog, ending
+// them with "...[truncated]" within the limit, or only cutting them if the
+// limit is shorter than that. Characters are never split, and fields are not
+// counted. A limit of zero or less disables truncation.
+// Disabled (0) by default.
+func SetMaxMessageBytes(n int) {
+	std.SetMaxMessageBytes(n)
+}
+
+// Adds the file, line and function of the code calling the logging function
+// in front of messages, as "file.go:123 main.handler", using the supplied
+// boolean. Messages logged through StdLogger, Writer or NewSlogHandler report
+// the code calling the standard logger.
+// Off (false) by default.
+func SetCaller(b bool) {
+	std.SetCaller(b)
+}
+
+// Sets how SetCaller shows the caller: CALLER_BOTH as "file.go:123
+// main.handler", CALLER_FILE as "file.go:123", CALLER_FUNC as the function
+// only, such as "main.(*Server).handle", which changes less when the code is
+// edited.
+// CALLER_BOTH by default.
+func SetCallerStyle(style int) {
+	std.SetCallerStyle(style)
+}
+
+// Sets the number of frames to skip above the code calling the logging
+// function when looking for the caller, so that wrappers of the logging
+// functions report their own callers.
+// 0 by default.
+func SetCallerSkip(skip int) {
+	std.SetCallerSkip(skip)
+}
+
+// Sets a function called for each message that passes the level and
+// verbose settings, before it is printed or sent to Syslog, with the level
+// and the message as it is logged (including the caller and the fields).
+// Useful in tests to record what was logged. Nil removes it.
+func SetHook(hook func(level int, message string)) {
+	std.SetHook(hook)
+}
+
+// Adds a hook called for each message of the given levels, or of all levels
+// if none is given, that passes the level and verbose settings. Hooks are
+// called in the order they were added, before the function set with
+// SetHook, and may change the fields of the message. Their errors are
+// joined to the error returned by the logging functions. See
+// SetHookWorkers to call them in the background instead.
+func AddHook(h Hook, levels ...int) {
+	std.AddHook(h, levels...)
+}
+
+// Calls the hooks added with AddHook on n background goroutines, so that a
+// slow hook, such as one posting to a chat service, does not delay logging.
+// Up to HOOK_QUEUE calls wait for a goroutine. The hooks then receive their
+// own copy of the fields, whose changes are not logged, and their errors are
+// returned by Flush instead. Close waits for the queued calls. Zero or less
+// calls the hooks while logging, after the queued calls.
+// Off (0) by default.
+func SetHookWorkers(n int) {
+	std.SetHookWorkers(n)
+}
+
+// Sets what happens when the queue of the hook workers is full: ASYNC_BLOCK
+// waits for room, ASYNC_DROP skips the call, counting the message as
+// suppressed.
+// ASYNC_BLOCK by default.
+func SetHookOverflow(mode int) {
+	std.SetHookOverflow(mode)
+}
+
+// Replaces the text matching a pattern with "[REDACTED]" in messages and in
+// the values of fields, before they are passed to hooks or written. Values
+// other than strings, such as errors, are replaced by their redacted text if
+// they match.
+func AddRedaction(re *regexp.Regexp) {
+	std.AddRedaction(re)
+}
+
+// Replaces the values of the fields with the given keys, compared without
+// case, with "[REDACTED]" before they are passed to hooks or written.
+func RedactFields(keys ...string) {
+	std.RedactFields(keys...)
+}
+
+// Sets the style of the header of messages printed to screen.
+// STYLE_TEXT shows the level name, STYLE_SYMBOL a symbol and STYLE_BOTH the
+// symbol followed by the level name.
+// STYLE_TEXT by default.
+func SetStyle(st int) {
+	std.SetStyle(st)
+}
+
+// Omits the padding of level names in messages printed to screen using the
+// supplied boolean, for narrow terminals.
+// Off (false) by default.
+func SetCompact(b bool) {
+	std.SetCompact(b)
+}
+
+// Replaces the symbols used by the symbol styles for the given levels.
+// Levels missing from the map keep their current symbol.
+func SetSymbols(m map[int]string) {
+	std.SetSymbols(m)
+}
+
+// Enables the asynchronous mode, in which messages are queued in a buffer of
+// the given size and written to the screen, Syslog and the sinks by a
+// background goroutine, so that logging does not block on slow outputs.
+// Errors from the outputs are then returned by Flush instead. Close writes
+// the queued messages before returning. A size of zero or less disables the
+// mode, after writing the queued messages.
+// Off by default.
+func SetAsync(bufferSize int) {
+	std.SetAsync(bufferSize)
+}
+
+// Waits for the messages queued by the asynchronous mode to be written to
+// the screen, Syslog and the sinks, without stopping the logger. Does
+// nothing if the asynchronous mode is off.
+// Returns the last error from the outputs since the previous Flush.
+func Flush() error {
+	return std.Flush()
+}
+
+// Sets what happens when the buffer of the asynchronous mode is full:
+// ASYNC_BLOCK waits for room, ASYNC_DROP drops the oldest queued message,
+// counting it as suppressed. Messages logged while a Flush is waiting may
+// wait for room too, as its request is never dropped.
+// ASYNC_BLOCK by default.
+func SetAsyncOverflow(mode int) {
+	std.SetAsyncOverflow(mode)
+}
+
+// Returns the number of messages logged per level since the start of the
+// program or the last ResetStats. Messages filtered out by the level or
+// suppressed are not counted.
+func Stats() map[int]uint64 {
+	return std.Stats()
+}
+
+// Returns the number of messages logged at the given level, see Stats.
+// Returns 0 for levels out of range.
+func Count(level int) uint64 {
+	return std.Count(level)
+}
+
+// Sets the number of messages logged at every level back to zero.
+func ResetStats() {
+	std.ResetStats()
+}
+
+// Replaces the color used for the header of the given level with an ANSI
+// escape sequence, such as one of the C_* colors or one given by Color256 or
+// ColorRGB. Levels out of range are ignored.
+func SetLevelColor(level int, ansi string) {
+	std.SetLevelColor(level, ansi)
+}
+
+// Restores the default colors of all levels.
+func ResetColors() {
+	std.ResetColors()
+}
+
+// Returns the ANSI escape sequence of a foreground color from the 256-color
+// palette, for SetLevelColor.
+func Color256(n uint8) string {
+	return "\x1b[38;5;" + strconv.Itoa(int(n)) + "m"
+}
+
+// Returns the ANSI escape sequence of a 24-bit foreground color, for
+// SetLevelColor. Unless COLORTERM is "truecolor" or "24bit", the terminal is
+// not assumed to support them and the closest color of the 256-color palette
+// is used instead.
+func ColorRGB(r, g, b uint8) string {
+	if ct := os.Getenv("COLORTERM"); ct == "truecolor" || ct == "24bit" {
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
+	}
+	cube := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+	return Color256(uint8(16 + 36*cube(r) + 6*cube(g) + cube(b)))
+}
+
+// Limits the number of messages logged per second. Messages beyond the
+// limit are dropped, and a Warning telling how many were dropped is logged
+// a second after the first drop. Emergency messages are never dropped.
+// A zero or negative limit disables it. Off (0) by default.
+func SetRateLimit(maxPerSecond int) {
+	std.SetRateLimit(maxPerSecond)
+}
+
+// Sets the number of messages that can be logged at once when the rate
+// limit has not been reached for a while, such as at the start of an
+// incident. The rate limit still applies on average.
+// The rate limit by default.
+func SetRateLimitBurst(burst int) {
+	std.SetRateLimitBurst(burst)
+}
+
+// Returns the number of messages dropped by the rate limit since the start
+// of the program.
+func RateLimited() uint64 {
+	return std.RateLimited()
+}
+
+// Collapses consecutive identical messages (same level and text) using the
+// supplied boolean. Repeats are counted instead of logged, and a line such as
+// "last message repeated 15 times" is logged when a different message
+// arrives or after a few seconds.
+// Off (false) by default.
+func SetDedup(b bool) {
+	std.SetDedup(b)
+}
+
+// Samples messages of the given level: the first occurrences of each
+// distinct message always pass, then only the given fraction (0 to 1) of
+// the following occurrences are logged. Occurrences are counted again every
+// minute, or sooner once 10000 distinct messages were seen.
+// Calling it with thereafter set to 1 or more disables sampling for the
+// level. Replaces the sampling set with SetSampling. Off by default.
+func SetSampleBurst(level int, first int, thereafter float64) {
+	std.SetSampleBurst(level, first, thereafter)
+}
+
+// Samples messages of the given level deterministically: the first
+// occurrences of each distinct message always pass, then one in every
+// following occurrences is logged, such as SetSampling(L_WARNING, 1, 100) to
+// log the first warning then every 100th. Occurrences are counted as with
+// SetSampleBurst, which it replaces.
+// Calling it with every set to 1 or less disables sampling for the level.
+// Off by default.
+func SetSampling(level int, first int, every int) {
+	std.SetSampling(level, first, every)
+}
+
+// Logs a Notice every d with the number of messages suppressed by sampling,
+// rate limiting or deduplication during that time, if any. A zero or
+// negative duration stops the reports.
+// Off (0) by default.
+func SetSuppressionReportInterval(d time.Duration) {
+	std.SetSuppressionReportInterval(d)
+}
+
+// Keeps up to n messages logged before Open in memory and sends them to
+// Syslog once Open succeeds. When the buffer is full, the oldest message is
+// dropped. Messages printed on screen are not affected.
+// Off (0) by default.
+func BufferUntilOpen(n int) {
+	std.bufferUntilOpen(n)
 }
 
 // Sets the logging to debug mode using the supplied boolean.
@@ -159,7 +780,15 @@ func DisableColor() {
 // sent to Syslog.
 // Off (false) by default.
 func SetDebug(b bool) {
-	debug = b
+	std.SetDebug(b)
+}
+
+// Sets the logging to mirror mode using the supplied boolean.
+// When set to true, logs are both printed on screen and sent to Syslog,
+// whatever the debug mode.
+// Off (false) by default.
+func SetMirror(b bool) {
+	std.SetMirror(b)
 }
 
 // Sets the logging to verbose mode using the supplied boolean.
@@ -167,103 +796,404 @@ func SetDebug(b bool) {
 // Otherwise, they are simply ignored.
 // Off (false) by default.
 func SetVerbose(b bool) {
-	verbose = b
+	std.SetVerbose(b)
 }
 
 // Logs an Emergency-evel event.
 // Emergency messages will always be sent to Syslog and printed on screen.
 // Returns an error if unable to log it.
 func Emerg(message string) error {
-	PrintToScreen(L_EMERGENCY, message)
-	err := s.Emerg(message)
-	return err
+	return std.Emerg(message)
 }
 
 // Logs an Alert-level event.
 // Alert messages will always be sent to Syslog and printed on screen.
 // Returns an error if unable to log it.
 func Alert(message string) error {
-	PrintToScreen(L_ALERT, message)
-	err := s.Alert(message)
-	return err
+	return std.Alert(message)
 }
 
 // Logs a Critical-level event.
 // Returns an error if unable to log it.
 func Crit(message string) error {
-	if debug == true {
-		PrintToScreen(L_CRITICAL, message)
-		return nil
-	} else {
-		err := s.Crit(message)
-		return err
-	}
+	return std.Crit(message)
 }
 
 // Logs an Error-level event.
 // Returns an error if unable to log it.
 func Err(message string) error {
-	if debug == true {
-		PrintToScreen(L_ERROR, message)
-		return nil
-	} else {
-		err := s.Err(message)
-		return err
-	}
+	return std.Err(message)
 }
 
 // Logs a Warning-level event.
 // Returns an error if unable to log it.
 func Warning(message string) error {
-	if debug == true {
-		PrintToScreen(L_WARNING, message)
-		return nil
-	} else {
-		err := s.Warning(message)
-		return err
-	}
+	return std.Warning(message)
 }
 
 // Logs a Notice-level event.
 // Returns an error if unable to log it.
 func Notice(message string) error {
-	if debug == true {
-		PrintToScreen(L_NOTICE, message)
-		return nil
-	} else {
-		err := s.Notice(message)
-		return err
-	}
+	return std.Notice(message)
 }
 
 // Logs an Info-level event.
 // Will not be logged unless Verbose is set to true.
 // Returns an error if unable to log it.
 func Info(message string) error {
-	if verbose == true {
-		if debug == true {
-			PrintToScreen(L_INFO, message)
-			return nil
-		} else {
-			err := s.Info(message)
-			return err
-		}
-	}
-	return nil
+	return std.Info(message)
 }
 
 // Logs a Debug-level event.
 // Will not be logged unless Verbose is set to true.
 // Returns an error if unable to log it.
 func Debug(message string) error {
-	if verbose == true {
-		if debug == true {
-			PrintToScreen(L_DEBUG, message)
-			return nil
-		} else {
-			err := s.Debug(message)
-			return err
-		}
-	}
-	return nil
+	return std.Debug(message)
+}
+
+// Logs an Emergency-level event, formatted according to a format specifier.
+// Follows the same rules as Emerg.
+// Returns an error if unable to log it.
+func Emergencyf(format string, a ...interface{}) error {
+	return std.Emergencyf(format, a...)
+}
+
+// Logs an Alert-level event, formatted according to a format specifier.
+// Follows the same rules as Alert.
+// Returns an error if unable to log it.
+func Alertf(format string, a ...interface{}) error {
+	return std.Alertf(format, a...)
+}
+
+// Logs a Critical-level event, formatted according to a format specifier.
+// Follows the same rules as Crit.
+// Returns an error if unable to log it.
+func Criticalf(format string, a ...interface{}) error {
+	return std.Criticalf(format, a...)
+}
+
+// Logs an Error-level event, formatted according to a format specifier.
+// Follows the same rules as Err.
+// Returns an error if unable to log it.
+func Errorf(format string, a ...interface{}) error {
+	return std.Errorf(format, a...)
+}
+
+// Logs a Warning-level event, formatted according to a format specifier.
+// Follows the same rules as Warning.
+// Returns an error if unable to log it.
+func Warningf(format string, a ...interface{}) error {
+	return std.Warningf(format, a...)
+}
+
+// Logs a Notice-level event, formatted according to a format specifier.
+// Follows the same rules as Notice.
+// Returns an error if unable to log it.
+func Noticef(format string, a ...interface{}) error {
+	return std.Noticef(format, a...)
+}
+
+// Logs an Info-level event, formatted according to a format specifier.
+// Follows the same rules as Info.
+// Returns an error if unable to log it.
+func Infof(format string, a ...interface{}) error {
+	return std.Infof(format, a...)
+}
+
+// Logs a Debug-level event, formatted according to a format specifier.
+// Follows the same rules as Debug.
+// Returns an error if unable to log it.
+func Debugf(format string, a ...interface{}) error {
+	return std.Debugf(format, a...)
+}
+
+// Returns the current logging configuration.
+// Useful to log how logging was configured at startup.
+func Snapshot() Config {
+	return std.Snapshot()
+}
+
+// Applies a logging configuration, typically obtained from Snapshot. Colors
+// stay disabled if the outputs are not terminals.
+func Apply(c Config) {
+	std.Apply(c)
+}
+
+// Logs a Critical-level event, then stops the logging system, writing any
+// queued message and closing the sinks, and exits the program with the
+// status set by SetExitCode. The message is never dropped by sampling, the
+// rate limit or deduplication.
+func Fatal(message string) {
+	std.Fatal(message)
+}
+
+// Sets the exit status of the program when Fatal is called.
+// 1 by default.
+func SetExitCode(code int) {
+	std.SetExitCode(code)
+}
+
+// Logs a Critical-level event formatted according to a format specifier,
+// then stops the logging system and exits the program with the status set
+// by SetExitCode.
+func Fatalf(format string, a ...interface{}) {
+	std.Fatalf(format, a...)
+}
+
+// Logs a Critical-level event, then panics with the message. The message is
+// written before panicking, even in asynchronous mode, and never dropped by
+// sampling, the rate limit or deduplication.
+func Panic(message string) {
+	std.Panic(message)
+}
+
+// Logs a Critical-level event formatted according to a format specifier,
+// then panics with the message.
+func Panicf(format string, a ...interface{}) {
+	std.Panicf(format, a...)
+}
+
+// Adds a sink receiving every message logged, in addition to the screen
+// and Syslog. Errors from sinks are joined to the error returned by the
+// logging functions. Sinks implementing io.Closer are closed by Close.
+func AddSink(s Sink) {
+	std.AddSink(s)
+}
+
+// Adds a writer receiving the messages logged at minLevel or more severe,
+// formatted as on screen, following SetFormat, SetStyle, SetTimeFormat and
+// the level colors, such as a buffer in tests or a network connection.
+// Colors are used only if w is a terminal and they are enabled.
+func AddWriter(w io.Writer, minLevel int) {
+	std.AddWriter(w, minLevel)
+}
+
+// Returns a channel receiving a copy of every message logged, with its
+// level, time and fields, such as to forward some of them elsewhere. Each
+// subscriber gets its own buffered channel; messages are dropped for
+// subscribers too slow to keep up, so that logging never blocks on them.
+func Subscribe() <-chan Event {
+	return std.Subscribe()
+}
+
+// Stops sending messages to a channel returned by Subscribe and closes it.
+func Unsubscribe(ch <-chan Event) {
+	std.Unsubscribe(ch)
+}
+
+// Returns the number of messages dropped because a subscriber was full.
+func DroppedEvents() uint64 {
+	return std.DroppedEvents()
+}
+
+// Returns a writer logging each line written to it at the given level.
+// Useful to capture the output of the standard log package or of libraries
+// writing to an io.Writer, for instance log.SetOutput(Writer(L_INFO)).
+func Writer(level int) io.Writer {
+	return std.Writer(level)
+}
+
+// Returns a standard *log.Logger logging each line at the given level,
+// without its own prefix or timestamp, for libraries that take one such as
+// http.Server.ErrorLog.
+func StdLogger(level int) *log.Logger {
+	return std.StdLogger(level)
+}
+
+// Returns an entry logging with the given structured fields, for instance
+// WithFields(Fields{"user_id": 42}).Err("login failed").
+// Calling WithFields on the entry merges the fields.
+func WithFields(fields Fields) *Entry {
+	return std.WithFields(fields)
+}
+
+// Returns an entry prepending the given prefix and a space to messages, such
+// as WithPrefix("[db]").Err("connection lost"). Calling WithPrefix on the
+// entry appends to the prefix.
+func WithPrefix(prefix string) *Entry {
+	return std.WithPrefix(prefix)
+}
+
+// Returns an entry logging as a named component, such as
+// Named("http.server"), shown as "[http.server]" in front of messages.
+// With Open on Unix, OpenRemote and OpenJournald, the name is also appended
+// to the tag, as in "myapp.http.server"; Open then connects to Syslog once
+// more for each component. The Windows Event Log uses one source for all
+// messages, so only the message shows it.
+// Calling Named on the entry adds a child component, as in
+// Named("http").Named("server").
+func Named(name string) *Entry {
+	return std.Named(name)
+}
+
+// Sets the least severe level logged by a component and its children,
+// replacing the level and verbose settings for them, such as
+// SetComponentLevel("db", L_DEBUG) to debug only the database code. A
+// child uses the level of its closest parent with one. A negative level
+// removes it.
+func SetComponentLevel(name string, level int) {
+	std.SetComponentLevel(name, level)
+}
+
+// Registers a context value to log as a field by the Context functions and
+// the slog handler. Takes the key of the value in the context and the name
+// of the field. Values missing from a context are omitted.
+func RegisterContextField(key interface{}, fieldName string) {
+	std.RegisterContextField(key, fieldName)
+}
+
+// Returns an entry logging as fields the fields of the entry stored in a
+// context by NewContext and the values registered with
+// RegisterContextField.
+func WithContext(ctx context.Context) *Entry {
+	return std.WithContext(ctx)
+}
+
+// Logs an Emergency-level event with the fields of a context, see
+// WithContext.
+// Returns an error if unable to log it.
+func EmergencyContext(ctx context.Context, message string) error {
+	return std.EmergencyContext(ctx, message)
+}
+
+// Logs an Alert-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func AlertContext(ctx context.Context, message string) error {
+	return std.AlertContext(ctx, message)
+}
+
+// Logs a Critical-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func CriticalContext(ctx context.Context, message string) error {
+	return std.CriticalContext(ctx, message)
+}
+
+// Logs an Error-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func ErrorContext(ctx context.Context, message string) error {
+	return std.ErrorContext(ctx, message)
+}
+
+// Logs a Warning-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func WarningContext(ctx context.Context, message string) error {
+	return std.WarningContext(ctx, message)
+}
+
+// Logs a Notice-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func NoticeContext(ctx context.Context, message string) error {
+	return std.NoticeContext(ctx, message)
+}
+
+// Logs an Info-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func InfoContext(ctx context.Context, message string) error {
+	return std.InfoContext(ctx, message)
+}
+
+// Logs a Debug-level event with the fields of a context, see WithContext.
+// Returns an error if unable to log it.
+func DebugContext(ctx context.Context, message string) error {
+	return std.DebugContext(ctx, message)
+}
+
+// Returns an entry logging an error as structured fields, such as
+// WithError(err).Err("request failed"): the message of the error in the
+// "error" field and its type in "errorType". A nil error adds no field.
+func WithError(err error) *Entry {
+	return std.WithError(err)
+}
+
+// Records the stack of the goroutine in the "stack" field of the messages
+// logged by the Err functions, such as ErrorErr, using the supplied boolean.
+// Off (false) by default.
+func SetErrorStack(b bool) {
+	std.SetErrorStack(b)
+}
+
+// Logs an Emergency-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func EmergencyErr(err error, message string) error {
+	return std.EmergencyErr(err, message)
+}
+
+// Logs an Alert-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func AlertErr(err error, message string) error {
+	return std.AlertErr(err, message)
+}
+
+// Logs a Critical-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func CriticalErr(err error, message string) error {
+	return std.CriticalErr(err, message)
+}
+
+// Logs an Error-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func ErrorErr(err error, message string) error {
+	return std.ErrorErr(err, message)
+}
+
+// Logs a Warning-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func WarningErr(err error, message string) error {
+	return std.WarningErr(err, message)
+}
+
+// Logs a Notice-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func NoticeErr(err error, message string) error {
+	return std.NoticeErr(err, message)
+}
+
+// Logs an Info-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func InfoErr(err error, message string) error {
+	return std.InfoErr(err, message)
+}
+
+// Logs a Debug-level event with an error. The message of the error is logged
+// in the "error" field, and those of the errors it wraps in "causes".
+// Returns an error if unable to log it.
+func DebugErr(err error, message string) error {
+	return std.DebugErr(err, message)
+}
+
+// Logs a message with per-message options.
+// Follows the same rules as the level functions unless ScreenOnly or
+// SyslogOnly is set.
+// Returns an error if unable to log it.
+func LogOpt(opts LogOptions) error {
+	return std.LogOpt(opts)
+}
+
+// Logs a Notice-level change of a value, as "name: before → after".
+// On screen, the old value is shown in red and the new one in green when
+// colors are used, following SetColorStyle.
+// Returns an error if unable to log it.
+func LogChange(name string, before, after interface{}) error {
+	return std.LogChange(name, before, after)
+}
+
+// Logs a message at the given level, unless a level was forced for key with
+// ForceLevel, in which case that level is used instead.
+// Returns an error if unable to log it.
+func LogKeyed(key string, level int, message string) error {
+	return std.LogKeyed(key, level, message)
+}
+
+// Forces the level of messages logged with LogKeyed under key, for instance
+// to make a Debug message show up without turning verbose on. A negative
+// level removes the override.
+func ForceLevel(key string, level int) {
+	std.ForceLevel(key, level)
 }
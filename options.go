@@ -0,0 +1,107 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import "io"
+
+// An option for the functions accepting them, such as New and
+// NewSlogHandler.
+type Option func(*options)
+
+// Settings collected from options.
+type options struct {
+	logger   *Logger
+	priority Priority
+	settings []func(*Logger)
+}
+
+// Uses the given logger instead of the default one.
+func WithLogger(l *Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// Sets the Syslog facility of a new logger, such as syslog.LOG_LOCAL0,
+// instead of syslog.LOG_DAEMON. Ignored on Windows.
+func WithFacility(facility Priority) Option {
+	return func(o *options) {
+		o.priority = facility&^7 | defaultPriority&7
+	}
+}
+
+// Sets the rate limit of a new logger and its burst, see SetRateLimit and
+// SetRateLimitBurst.
+func WithRateLimit(maxPerSecond, burst int) Option {
+	return setting(func(l *Logger) {
+		l.SetRateLimit(maxPerSecond)
+		l.SetRateLimitBurst(burst)
+	})
+}
+
+// Sets the hostname a new logger sends to remote Syslog servers, such as
+// with NewRemote, see SetHostname.
+func WithHostname(name string) Option {
+	return setting(func(l *Logger) { l.SetHostname(name) })
+}
+
+// Sets the debug mode of a new logger, see SetDebug.
+func WithDebug(b bool) Option {
+	return setting(func(l *Logger) { l.SetDebug(b) })
+}
+
+// Sets the verbose mode of a new logger, see SetVerbose.
+func WithVerbose(b bool) Option {
+	return setting(func(l *Logger) { l.SetVerbose(b) })
+}
+
+// Sets the least severe level logged by a new logger, see SetLevel.
+func WithLevel(level int) Option {
+	return setting(func(l *Logger) { l.SetLevel(level) })
+}
+
+// Sets the screen output of a new logger, see SetOutput.
+func WithOutput(w io.Writer) Option {
+	return setting(func(l *Logger) { l.SetOutput(w) })
+}
+
+// Returns an option applying a setting to a new logger before it starts.
+func setting(f func(*Logger)) Option {
+	return func(o *options) {
+		o.settings = append(o.settings, f)
+	}
+}
+
+// Applies options over the default settings.
+func applyOptions(opts []Option) *options {
+	o := &options{logger: std, priority: defaultPriority}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Applies the settings collected from options to a new logger.
+func (o *options) configure(l *Logger) {
+	for _, f := range o.settings {
+		f(l)
+	}
+}
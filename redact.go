@@ -0,0 +1,113 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Text replacing redacted content.
+const redacted = "[REDACTED]"
+
+// Redacts the text matching a pattern, see AddRedaction.
+func (l *Logger) AddRedaction(re *regexp.Regexp) {
+	l.mu.Lock()
+	l.redactions = append(l.redactions, re)
+	l.mu.Unlock()
+}
+
+// Redacts the values of fields, see RedactFields.
+func (l *Logger) RedactFields(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.redactedKeys == nil {
+		l.redactedKeys = make(map[string]bool)
+	}
+	for _, k := range keys {
+		l.redactedKeys[strings.ToLower(k)] = true
+	}
+}
+
+// Scrubs the message and the fields of a record. The fields are copied
+// before being changed.
+func (l *Logger) redact(e *record) {
+	l.mu.Lock()
+	redactions, keys := l.redactions, l.redactedKeys
+	l.mu.Unlock()
+
+	if len(redactions) == 0 && len(keys) == 0 {
+		return
+	}
+
+	message := redactString(e.message, redactions)
+	if message != e.message {
+		e.message = message
+		e.colored = ""
+	}
+
+	if len(e.fields) == 0 {
+		return
+	}
+	fields := make(Fields, len(e.fields))
+	for k, v := range e.fields {
+		if keys[strings.ToLower(k)] {
+			v = redacted
+		} else if len(redactions) > 0 {
+			v = redactValue(v, redactions)
+		}
+		fields[k] = v
+	}
+	e.fields = fields
+}
+
+// Scrubs a field value. Strings and string slices are scrubbed in place;
+// other values, such as errors, are replaced by their scrubbed text form if
+// it contains anything to redact, and kept as they are otherwise.
+func redactValue(v interface{}, redactions []*regexp.Regexp) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return redactString(v, redactions)
+	case []string:
+		s := make([]string, len(v))
+		for i := range v {
+			s[i] = redactString(v[i], redactions)
+		}
+		return s
+	}
+	s := fmt.Sprint(v)
+	if r := redactString(s, redactions); r != s {
+		return r
+	}
+	return v
+}
+
+// Replaces the text matching any of the patterns.
+func redactString(s string, redactions []*regexp.Regexp) string {
+	for _, re := range redactions {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
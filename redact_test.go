@@ -0,0 +1,59 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactNonStringFields(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.AddRedaction(regexp.MustCompile(`secret\d+`))
+
+	err := fmt.Errorf("request failed: %w", errors.New("token secret123 rejected"))
+	l.ErrorErr(err, "call")
+	l.WithFields(Fields{"err": errors.New("token secret456 rejected"), "n": 42}).Err("call")
+
+	out := strings.Join(l.DryRunOutput(), "\n")
+	if strings.Contains(out, "secret1") || strings.Contains(out, "secret4") {
+		t.Fatalf("secret not redacted:\n%s", out)
+	}
+	if !strings.Contains(out, "n=42") {
+		t.Fatalf("unrelated field changed:\n%s", out)
+	}
+}
+
+func TestRedactFieldsKeepsCallerMap(t *testing.T) {
+	l := newLogger()
+	l.SetDryRun(true)
+	l.AddRedaction(regexp.MustCompile(`secret\d+`))
+
+	causes := []string{"secret1"}
+	l.WithFields(Fields{"causes": causes}).Err("call")
+	if causes[0] != "secret1" {
+		t.Fatalf("caller slice changed to %q", causes[0])
+	}
+}
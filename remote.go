@@ -0,0 +1,164 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layout of RFC 5424 timestamps, which allow at most 6 fractional digits.
+const rfc5424Time = "2006-01-02T15:04:05.000000Z07:00"
+
+// Sends messages to a remote Syslog server in the RFC 5424 format,
+// reconnecting when the connection breaks. Over TCP, messages are framed
+// with octet counting as described in RFC 6587.
+type rfc5424Writer struct {
+	mu       sync.Mutex
+	network  string
+	raddr    string
+	facility int
+	hostname string
+	appName  string
+	conn     net.Conn
+}
+
+// Connects to a remote Syslog server. An empty hostname uses the name of
+// the machine.
+func dialRFC5424(network, raddr string, priority Priority, tag, hostname string) (*rfc5424Writer, error) {
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	w := &rfc5424Writer{
+		network:  network,
+		raddr:    raddr,
+		facility: int(priority) &^ 7,
+		hostname: hostname,
+		appName:  tag,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Connects to Syslog: to a remote server in the RFC 5424 format if network
+// is set, to journald if it is journaldNetwork, or to the local server
+// otherwise.
+func dial(network, raddr string, priority Priority, tag, hostname string) (syslogWriter, error) {
+	switch network {
+	case "":
+		return dialSyslog(network, raddr, priority, tag)
+	case journaldNetwork:
+		return dialJournald(tag)
+	}
+	return dialRFC5424(network, raddr, priority, tag, hostname)
+}
+
+// Opens the connection to the server. Must be called with the lock held,
+// or before the writer is shared.
+func (w *rfc5424Writer) connect() error {
+	conn, err := net.Dial(w.network, w.raddr)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// Sends a message with its structured data, which may be empty, appending
+// the component, if any, to the app-name. Reconnects and tries again once if
+// the connection is broken.
+func (w *rfc5424Writer) write(level int, ts time.Time, component, sd, message string) error {
+	if sd == "" {
+		sd = "-"
+	}
+	m := fmt.Sprintf("<%d>1 %s %s %s %d - %s", w.facility|level, ts.Format(rfc5424Time),
+		nilValue(w.hostname, 255), nilValue(componentTag(w.appName, component), 48), os.Getpid(), sd)
+	if message != "" {
+		m += " " + message
+	}
+	if strings.HasPrefix(w.network, "tcp") {
+		m = fmt.Sprintf("%d %s", len(m), m)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := io.WriteString(w.conn, m); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.conn, m)
+	return err
+}
+
+// Closes the connection.
+func (w *rfc5424Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *rfc5424Writer) Emerg(m string) error   { return w.write(L_EMERGENCY, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Alert(m string) error   { return w.write(L_ALERT, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Crit(m string) error    { return w.write(L_CRITICAL, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Err(m string) error     { return w.write(L_ERROR, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Warning(m string) error { return w.write(L_WARNING, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Notice(m string) error  { return w.write(L_NOTICE, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Info(m string) error    { return w.write(L_INFO, time.Now(), "", "", m) }
+func (w *rfc5424Writer) Debug(m string) error   { return w.write(L_DEBUG, time.Now(), "", "", m) }
+
+// Returns a header field of at most n printable characters, spaces being
+// replaced with underscores, or the nil value "-" if empty.
+func nilValue(s string, n int) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	if len(b) > n {
+		b = b[:n]
+	}
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
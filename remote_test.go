@@ -0,0 +1,137 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRFC5424Message(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	w, err := dialRFC5424("udp", pc.LocalAddr().String(), defaultPriority, "app", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	if err := w.write(L_ERROR, ts, "db", `[fields@32473 k="v"]`, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := regexp.MustCompile(`^<\d+>1 2026-01-02T03:04:05\.123456Z host app\.db \d+ - \[fields@32473 k="v"\] hello$`)
+	if got := string(buf[:n]); !want.MatchString(got) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSetTagClosesOldWriter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	l, err := NewRemote("udp", pc.LocalAddr().String(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+					l.Err(strconv.Itoa(n))
+				}
+			}
+		}()
+	}
+
+	var old []*rfc5424Writer
+	for deadline := time.Now().Add(100 * time.Millisecond); time.Now().Before(deadline); {
+		l.mu.Lock()
+		old = append(old, l.s.(*rfc5424Writer))
+		l.mu.Unlock()
+		if err := l.SetTag("app"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	for _, w := range old {
+		w.mu.Lock()
+		leaked := w.conn != nil
+		w.mu.Unlock()
+		if leaked {
+			t.Fatal("a writer replaced by SetTag reconnected after being closed")
+		}
+	}
+}
+
+func TestNewRemoteWithHostname(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	l, err := NewRemote("udp", pc.LocalAddr().String(), "app", WithHostname("web1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Err("hello")
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !regexp.MustCompile(`^<\d+>1 \S+ web1 app `).MatchString(got) {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// A destination for messages, in addition to the screen and Syslog.
+// Sinks receive every message logged, with its fields appended, and may
+// also implement io.Closer to be closed with the logger.
+type Sink interface {
+	Log(level int, ts time.Time, message string) error
+}
+
+// A sink formatting records itself, with the settings of the logger it was
+// added to, instead of receiving them through Log.
+type recordSink interface {
+	logRecord(l *Logger, e record) error
+}
+
+// Sends a record to a sink.
+func (l *Logger) logToSink(s Sink, e record) error {
+	if rs, ok := s.(recordSink); ok {
+		return rs.logRecord(l, e)
+	}
+	return s.Log(e.level, e.time, e.message+formatFields(e.fields))
+}
+
+// Adds a sink receiving every message logged, see AddSink.
+func (l *Logger) AddSink(s Sink) {
+	l.mu.Lock()
+	l.sinks = append(l.sinks, s)
+	l.mu.Unlock()
+}
+
+// A sink printing messages to a writer, up to a level.
+type screenSink struct {
+	w     io.Writer
+	color bool
+	level int
+}
+
+// Returns a sink printing messages to w with a colored header, following
+// the same rules as Open for colors. Once added to a logger, messages are
+// formatted as on screen, following its format, style and time settings.
+func NewScreenSink(w io.Writer) Sink {
+	return &screenSink{w: w, color: colorCapable(w), level: L_DEBUG}
+}
+
+// Adds a sink printing messages up to a level to w, see AddWriter.
+func (l *Logger) AddWriter(w io.Writer, minLevel int) {
+	l.AddSink(&screenSink{w: w, color: colorCapable(w), level: minLevel})
+}
+
+// Prints a message if its level is high enough.
+func (s *screenSink) Log(level int, ts time.Time, message string) error {
+	if level > s.level {
+		return nil
+	}
+	_, err := io.WriteString(s.w, formatSinkLine(level, ts, message, s.color))
+	return err
+}
+
+// Prints a record formatted as on screen if its level is high enough.
+func (s *screenSink) logRecord(l *Logger, e record) error {
+	if e.level > s.level {
+		return nil
+	}
+	_, err := io.WriteString(s.w, l.formatLine(e, s.color && l.colored()))
+	return err
+}
+
+// A sink writing messages to a rotated file.
+type fileSink struct {
+	f *rotatingFile
+}
+
+// Returns a sink writing messages to a file rotated when it would grow
+// beyond maxBytes, as with OpenFile. Once added to a logger, messages are
+// formatted as on screen without colors, following its format, style and
+// time settings.
+// Returns an error if unable to open the file.
+func NewFileSink(path string, maxBytes int64) (Sink, error) {
+	f, err := openRotatingFile(path, maxBytes, FILE_BACKUPS)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{f: f}, nil
+}
+
+// Writes a message.
+func (s *fileSink) Log(level int, ts time.Time, message string) error {
+	_, err := io.WriteString(s.f, formatSinkLine(level, ts, message, false))
+	return err
+}
+
+// Writes a record formatted as on screen, without colors.
+func (s *fileSink) logRecord(l *Logger, e record) error {
+	_, err := io.WriteString(s.f, l.formatLine(e, false))
+	return err
+}
+
+// Closes the file.
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
+
+// A sink sending messages to Syslog.
+type syslogSink struct {
+	w syslogWriter
+}
+
+// Returns a sink sending messages to a Syslog server with the given tag.
+// An empty network uses the local Syslog server, as with Open.
+// Returns an error if unable to connect to the server.
+func NewSyslogSink(network, raddr, tag string) (Sink, error) {
+	w, err := dial(network, raddr, defaultPriority, tag, "")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+// Sends a message.
+func (s *syslogSink) Log(level int, ts time.Time, message string) error {
+	return writeSyslog(s.w, level, message)
+}
+
+// Closes the connection to the server.
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
+
+// Formats a message for a sink, with the level header and the timestamp.
+func formatSinkLine(level int, ts time.Time, message string, colored bool) string {
+	mColor, mHeader := levelStyle(level)
+	mReset := C_RESET
+	if !colored {
+		mColor = ""
+		mReset = ""
+	}
+	return mColor + mHeader + mReset + " " + ts.Format(TIME_DEFAULT) + ": " + message + "\n"
+}
+
+// A message kept by a RingSink.
+type RingEntry struct {
+	Level   int
+	Time    time.Time
+	Message string
+}
+
+// A sink keeping the most recent messages in memory, such as to show them
+// on a status page. Safe for concurrent use.
+type RingSink struct {
+	mu      sync.Mutex
+	entries []RingEntry
+	next    int
+	full    bool
+}
+
+// Returns a sink keeping the given number of most recent messages. Older
+// messages are overwritten when it is full.
+func NewRingSink(capacity int) *RingSink {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingSink{entries: make([]RingEntry, capacity)}
+}
+
+// Keeps a message, overwriting the oldest one if full.
+func (s *RingSink) Log(level int, ts time.Time, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[s.next] = RingEntry{level, ts, message}
+	s.next++
+	if s.next == len(s.entries) {
+		s.next = 0
+		s.full = true
+	}
+	return nil
+}
+
+// Returns a copy of the messages kept, from the oldest to the newest.
+func (s *RingSink) Entries() []RingEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]RingEntry(nil), s.entries[:s.next]...)
+	}
+	entries := make([]RingEntry, 0, len(s.entries))
+	entries = append(entries, s.entries[s.next:]...)
+	return append(entries, s.entries[:s.next]...)
+}
+
+// Formats the messages written by a sink, such as one created by
+// NewUnixSocketSink, including the line ending if any.
+type Encoder interface {
+	Encode(e Event) ([]byte, error)
+}
+
+// An Encoder writing each message as a JSON object on its own line, as with
+// FORMAT_JSON and the default keys, whatever the settings of the logger.
+var JSONEncoder Encoder = jsonEncoder{}
+
+// The encoder behind JSONEncoder.
+type jsonEncoder struct{}
+
+// Formats a message as a JSON object on a single line.
+func (jsonEncoder) Encode(e Event) ([]byte, error) {
+	f := lineFormat{timeFormat: TIME_DEFAULT, messageKey: "msg", timeKey: "time", levelKey: "level", now: time.Now}
+	return []byte(f.json(record{level: e.Level, time: e.Time, message: e.Message, fields: e.Fields})), nil
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterSinkFollowsFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(io.Discard)
+	l.SetFormat(FORMAT_JSON)
+	l.SetTimeFormat(TIME_NONE)
+	l.AddWriter(&buf, L_ERROR)
+
+	path := filepath.Join(t.TempDir(), "sink.log")
+	fs, err := NewFileSink(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.(io.Closer).Close()
+	l.AddSink(fs)
+
+	l.WithFields(Fields{"k": "v"}).Err("hi")
+	l.Notice("filtered")
+
+	want := `{"level":"ERROR","msg":"hi","k":"v"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writer got %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != want+`{"level":"NOTICE","msg":"filtered"}`+"\n" {
+		t.Errorf("file got %q", got)
+	}
+}
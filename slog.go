@@ -0,0 +1,121 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// A slog.Handler logging records through a Logger.
+type slogHandler struct {
+	logger *Logger
+	fields Fields
+	group  string
+}
+
+// Returns a slog.Handler logging through the default logger, or the one
+// given with WithLogger. Levels are mapped to L_DEBUG, L_INFO, L_WARNING and
+// L_ERROR, and attributes are logged as fields, prefixed by their groups,
+// along with the context values registered with RegisterContextField.
+func NewSlogHandler(opts ...Option) slog.Handler {
+	return &slogHandler{logger: applyOptions(opts).logger}
+}
+
+// Returns the level matching a slog level.
+func slogLevel(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return L_ERROR
+	case level >= slog.LevelWarn:
+		return L_WARNING
+	case level >= slog.LevelInfo:
+		return L_INFO
+	default:
+		return L_DEBUG
+	}
+}
+
+// Tells if records of the given level are logged.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.enabled(slogLevel(level))
+}
+
+// Logs a record with its attributes as fields.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := h.copyFields(r.NumAttrs())
+	for k, v := range h.logger.fieldsFromContext(ctx) {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+	return h.logger.write(record{level: slogLevel(r.Level), time: r.Time, message: r.Message, fields: fields, pc: r.PC})
+}
+
+// Returns a handler adding the given attributes to each record.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := h.copyFields(len(attrs))
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &slogHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+// Returns a handler prefixing the keys of the following attributes with the
+// group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, fields: h.fields, group: h.group + name + "."}
+}
+
+// Returns a copy of the fields of the handler, with room for n more.
+func (h *slogHandler) copyFields(n int) Fields {
+	fields := make(Fields, len(h.fields)+n)
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Adds an attribute to fields, prefixing its key. Group attributes are
+// flattened and empty attributes ignored.
+func addAttr(fields Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+
+	fields[prefix+a.Key] = a.Value.Any()
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// A sink writing encoded messages to a Unix domain socket.
+type unixSocketSink struct {
+	mu   sync.Mutex
+	path string
+	enc  Encoder
+	conn net.Conn
+}
+
+// Returns a sink writing messages to the Unix domain socket at path, such as
+// one of a log shipping agent, encoded with enc. A nil Encoder formats them
+// as on screen without colors, following the format, style and time
+// settings of the logger the sink is added to. The sink connects again if a
+// write fails, such as after the agent restarted.
+// Returns an error if unable to connect to the socket.
+func NewUnixSocketSink(path string, enc Encoder) (Sink, error) {
+	s := &unixSocketSink{path: path, enc: enc}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Connects to the socket. Must be called with the lock held.
+func (s *unixSocketSink) connect() error {
+	conn, err := net.Dial("unix", s.path)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// Writes a message, encoded or with the level header and the timestamp.
+func (s *unixSocketSink) Log(level int, ts time.Time, message string) error {
+	if s.enc == nil {
+		return s.write([]byte(formatSinkLine(level, ts, message, false)))
+	}
+	b, err := s.enc.Encode(Event{Level: level, Time: ts, Message: message})
+	if err != nil {
+		return err
+	}
+	return s.write(b)
+}
+
+// Writes a record with its fields, encoded or formatted as on screen.
+func (s *unixSocketSink) logRecord(l *Logger, e record) error {
+	if s.enc == nil {
+		return s.write([]byte(l.formatLine(e, false)))
+	}
+	b, err := s.enc.Encode(Event{Level: e.level, Time: e.time, Message: e.message, Fields: e.fields.clone()})
+	if err != nil {
+		return err
+	}
+	return s.write(b)
+}
+
+// Writes to the socket, connecting again once if the write fails.
+func (s *unixSocketSink) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write(b); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(b)
+	return err
+}
+
+// Closes the connection.
+func (s *unixSocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
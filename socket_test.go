@@ -0,0 +1,83 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Accepts a connection on a Unix socket and returns its first line.
+func readSocketLine(t *testing.T, ln net.Listener) string {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
+
+func TestUnixSocketSinkReconnects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	s, err := NewUnixSocketSink(path, JSONEncoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newLogger()
+	l.SetDebug(true)
+	l.SetScreenOutput(&strings.Builder{})
+	l.AddSink(s)
+	defer s.(*unixSocketSink).Close()
+
+	l.WithFields(Fields{"k": "v"}).Err("first")
+	if line := readSocketLine(t, ln); !strings.Contains(line, `"msg":"first","k":"v"}`) {
+		t.Fatalf("got %q", line)
+	}
+
+	// Restart the agent.
+	ln.Close()
+	os.Remove(path)
+	if ln, err = net.Listen("unix", path); err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	l.Err("second")
+	if line := readSocketLine(t, ln); !strings.Contains(line, `"msg":"second"}`) {
+		t.Fatalf("got %q", line)
+	}
+}
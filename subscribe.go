@@ -0,0 +1,86 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Messages buffered for each subscriber before dropping new ones.
+const subscriberBuffer = 64
+
+// A message logged, as received by subscribers. Each subscriber receives its
+// own copy of the fields.
+type Event struct {
+	Level   int
+	Time    time.Time
+	Message string
+	Fields  Fields
+}
+
+// Returns a channel receiving the messages logged, see Subscribe.
+func (l *Logger) Subscribe() <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	l.mu.Lock()
+	l.subscribers = append(l.subscribers, ch)
+	l.mu.Unlock()
+	return ch
+}
+
+// Stops sending messages to a channel and closes it, see Unsubscribe.
+func (l *Logger) Unsubscribe(ch <-chan Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, sub := range l.subscribers {
+		if sub == ch {
+			l.subscribers = append(l.subscribers[:i], l.subscribers[i+1:]...)
+			close(sub)
+			return
+		}
+	}
+}
+
+// Returns the number of messages dropped because a subscriber was full, see
+// DroppedEvents.
+func (l *Logger) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&l.eventDrops)
+}
+
+// Sends a message to the subscribers, dropping it for those that are full.
+func (l *Logger) publish(e record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.subscribers) == 0 {
+		return
+	}
+
+	for _, sub := range l.subscribers {
+		ev := Event{Level: e.level, Time: e.time, Message: e.message, Fields: e.fields.clone()}
+		select {
+		case sub <- ev:
+		default:
+			atomic.AddUint64(&l.eventDrops, 1)
+		}
+	}
+}
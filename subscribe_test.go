@@ -0,0 +1,47 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"io"
+	"testing"
+)
+
+func TestEventFieldsCopied(t *testing.T) {
+	for _, async := range []int{0, 8} {
+		l := newLogger()
+		l.SetDebug(true)
+		l.SetScreenOutput(io.Discard)
+		l.SetAsync(async)
+		ch := l.Subscribe()
+
+		e := l.WithFields(Fields{"a": 1})
+		e.Err("message")
+		e.Fields["b"] = 2
+		l.Flush()
+
+		ev := <-ch
+		if len(ev.Fields) != 1 {
+			t.Errorf("async %d: event fields changed after logging: %v", async, ev.Fields)
+		}
+		l.stopAsync()
+	}
+}
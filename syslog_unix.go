@@ -0,0 +1,95 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build !windows
+
+package logger
+
+import (
+	"log/syslog"
+	"sync"
+)
+
+// Syslog facility and severity, such as syslog.LOG_LOCAL0|syslog.LOG_INFO.
+type Priority = syslog.Priority
+
+// Facility and severity used by Open.
+const defaultPriority = syslog.LOG_WARNING | syslog.LOG_DAEMON
+
+// Connects to a Syslog server. An empty network uses the local Syslog
+// server.
+func dialSyslog(network, raddr string, priority Priority, tag string) (syslogWriter, error) {
+	w, err := syslog.Dial(network, raddr, priority, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &componentSyslog{Writer: w, network: network, raddr: raddr, priority: priority, tag: tag}, nil
+}
+
+// A connection to a Syslog server appending the component of messages to
+// the tag, as in "myapp.db". As log/syslog sends one tag per connection,
+// another one is opened for each component when it first logs.
+type componentSyslog struct {
+	*syslog.Writer
+	network    string
+	raddr      string
+	priority   Priority
+	tag        string
+	mu         sync.Mutex
+	components map[string]*syslog.Writer
+}
+
+// Sends a message at the given level, with the component appended to the
+// tag.
+func (w *componentSyslog) writeComponent(level int, component, message string) error {
+	if component == "" {
+		return writeSyslog(w.Writer, level, message)
+	}
+
+	w.mu.Lock()
+	cw, ok := w.components[component]
+	if !ok {
+		var err error
+		cw, err = syslog.Dial(w.network, w.raddr, w.priority, componentTag(w.tag, component))
+		if err != nil {
+			w.mu.Unlock()
+			return err
+		}
+		if w.components == nil {
+			w.components = make(map[string]*syslog.Writer)
+		}
+		w.components[component] = cw
+	}
+	w.mu.Unlock()
+	return writeSyslog(cw, level, message)
+}
+
+// Closes the connections.
+func (w *componentSyslog) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	errs := []error{w.Writer.Close()}
+	for _, cw := range w.components {
+		errs = append(errs, cw.Close())
+	}
+	w.components = nil
+	return joinErrors(errs...)
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2018 ARClab, Lionel Riem - https://arclab.ch/
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+// An io.Writer logging each line written at a fixed level.
+type levelWriter struct {
+	logger *Logger
+	level  int
+}
+
+// Returns a writer logging each line written to it at the given level, for
+// instance to use with log.SetOutput.
+func (l *Logger) Writer(level int) io.Writer {
+	return &levelWriter{logger: l, level: level}
+}
+
+// Returns a standard logger logging each line at the given level, see
+// StdLogger.
+func (l *Logger) StdLogger(level int) *log.Logger {
+	return log.New(l.Writer(level), "", 0)
+}
+
+// Logs each line of p as a separate message, ignoring empty lines.
+// Returns the first error encountered, if any.
+func (w *levelWriter) Write(p []byte) (int, error) {
+	var err error
+	for _, line := range strings.Split(string(p), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		if e := w.logger.output(w.level, line); e != nil && err == nil {
+			err = e
+		}
+	}
+	return len(p), err
+}